@@ -0,0 +1,211 @@
+// Package analysis re-evaluates every decision of a finished game, and rates how well each player played.
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/game/turngen"
+	"github.com/seriesoftubes/bgo/learn"
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+const (
+	errorRateScale = 1000 // Error rates are in millipoints of equity lost per unforced decision, like in gnubg.
+	prScale        = 500  // Performance ratings are half of the error rate, like in eXtreme Gammon.
+)
+
+type (
+	// A MoveAnalysis compares one turn that was played against the best turn.
+	MoveAnalysis struct {
+		Num                int // The index of the decision in the game's history, starting at 1.
+		Player             string
+		Roll               string
+		Played, PlayedDesc string // The serialized turn, and the turn in standard notation.
+		Best, BestDesc     string
+		EquityLoss         float32 // In a match, it's the equity that's worth as much as the MWC that was lost.
+		Quality            string
+		Forced             bool    // Whether there were fewer than 2 turns to choose from.
+		Luck               float32 // How much more equity the roll gave the player than the average roll would have.
+	}
+
+	// A PlayerSummary rates one player's decisions throughout the game.
+	PlayerSummary struct {
+		Player                      string
+		UnforcedDecisions           int
+		TotalEquityLoss             float32
+		Doubtfuls, Errors, Blunders int
+		ErrorRate                   float64 // Millipoints of equity lost per unforced decision.
+		PerformanceRating           float64 // Like the error rate, but on eXtreme Gammon's scale, where lower is better.
+		Rolls                       int
+		TotalLuck                   float32 // Positive if the player rolled better than average.
+	}
+
+	Options struct {
+		Ply        int          // How many rolls ahead to look when judging turns.
+		LuckPly    int          // How many rolls ahead to look after each possible roll when judging luck.
+		Filter     learn.Filter // Which turns get looked at further ahead. The zero Filter looks at all of them.
+		Thresholds learn.Thresholds
+		Match      *learn.Match // The score that the game was played at, if it's a game of a match. nil analyzes it for money.
+	}
+
+	Report struct {
+		GameID  uint32
+		Ply     int
+		LuckPly int
+		Match   string // The score that turns were judged at, or "" if they were judged by money equity.
+		Moves   []MoveAnalysis
+		Players []*PlayerSummary
+	}
+)
+
+// Analyze replays `g` from the starting position, compares each of its turns against `nets`' best turn, and rates the luck of each roll.
+// Resignations, doubles and timeouts aren't analyzed. In a match, turns and rolls are judged by match winning chances at the game's
+// score and cube, which are converted into the equity that's worth as much, so that thresholds and ratings mean the same as for money.
+func Analyze(nets *nnet.Set, g *game.Game, opts Options) (*Report, error) {
+	for _, ply := range []int{opts.Ply, opts.LuckPly} {
+		if ply < 0 || ply > learn.MaxPly {
+			return nil, fmt.Errorf("ply must be between 0 and %d, but got %d", learn.MaxPly, ply)
+		}
+	}
+
+	rep := &Report{GameID: g.ID, Ply: opts.Ply, LuckPly: opts.LuckPly}
+	if opts.Match != nil {
+		rep.Match = opts.Match.String()
+	}
+	summaries := map[plyr.Player]*PlayerSummary{}
+	for _, p := range []plyr.Player{plyr.PCC, plyr.PC} {
+		summaries[p] = &PlayerSummary{Player: p.Symbol()}
+		rep.Players = append(rep.Players, summaries[p])
+	}
+
+	search, luckSearch := learn.NewSearch(nets, opts.Ply), learn.NewSearch(nets, opts.LuckPly)
+	search.Filter, luckSearch.Filter = opts.Filter, opts.Filter
+
+	b := &game.Board{}
+	b.SetUp()
+	cubeValue := 1
+	for i, he := range g.History {
+		if he.Doubled && he.DoubleTaken {
+			cubeValue *= 2
+		}
+		if he.TimedOut || he.ResignationOffer != game.WinKindNotWon || he.Doubled {
+			continue // The player rolled this roll again in the next entry, unless the game ended here.
+		}
+		// equity converts an amount of MWC into equity in a match, and leaves equity as it is otherwise.
+		equity := func(amount float32) float32 {
+			if opts.Match == nil {
+				return amount
+			}
+			return opts.Match.EquivalentEquity(he.Player, amount, cubeValue)
+		}
+
+		validTurns := turngen.ValidTurns(b, he.Roll, he.Player)
+		ma := MoveAnalysis{
+			Num:        i + 1,
+			Player:     he.Player.Symbol(),
+			Roll:       fmt.Sprintf("%d%d", he.Roll[0], he.Roll[1]),
+			Played:     he.Turn.String(),
+			PlayedDesc: b.Notation(he.Turn),
+			Quality:    learn.QualityGood.String(),
+			Forced:     len(validTurns) < 2,
+			Luck:       equity(luckSearch.InMatch(opts.Match, cubeValue).RollLuck(b, he.Roll, he.Player)),
+		}
+		summaries[he.Player].Rolls++
+		summaries[he.Player].TotalLuck += ma.Luck
+		ma.Best, ma.BestDesc = ma.Played, ma.PlayedDesc
+		if !ma.Forced {
+			loss, best := search.InMatch(opts.Match, cubeValue).EquityLoss(b, validTurns, he.Turn, he.Player)
+			loss = equity(loss)
+			quality := opts.Thresholds.Classify(loss)
+			ma.EquityLoss, ma.Quality = loss, quality.String()
+			if loss > 0 {
+				ma.Best, ma.BestDesc = best.Turn.String(), b.Notation(best.Turn)
+			}
+			summaries[he.Player].record(loss, quality)
+		}
+		rep.Moves = append(rep.Moves, ma)
+
+		if ok, reason := b.ExecuteTurnIfLegal(he.Turn); !ok {
+			return nil, fmt.Errorf("decision #%d: illegal turn %v: %s", i+1, he.Turn, reason)
+		}
+	}
+
+	for _, ps := range rep.Players {
+		ps.summarize()
+	}
+	return rep, nil
+}
+
+func (ps *PlayerSummary) record(loss float32, quality learn.MoveQuality) {
+	ps.UnforcedDecisions++
+	ps.TotalEquityLoss += loss
+	switch quality {
+	case learn.QualityDoubtful:
+		ps.Doubtfuls++
+	case learn.QualityError:
+		ps.Errors++
+	case learn.QualityBlunder:
+		ps.Blunders++
+	}
+}
+
+func (ps *PlayerSummary) summarize() {
+	if ps.UnforcedDecisions == 0 {
+		return
+	}
+	avgLoss := float64(ps.TotalEquityLoss) / float64(ps.UnforcedDecisions)
+	ps.ErrorRate, ps.PerformanceRating = errorRateScale*avgLoss, prScale*avgLoss
+}
+
+// WriteTable writes a human-readable report, where the turns that weren't the best are marked with their quality.
+func (r *Report) WriteTable(w io.Writer) error {
+	fmt.Fprintf(w, "Analysis of game %d at %d-ply, with luck at %d-ply\n", r.GameID, r.Ply, r.LuckPly)
+	if r.Match != "" {
+		fmt.Fprintf(w, "Judged by match winning chances at %s, in equivalent equity\n", r.Match)
+	} else {
+		fmt.Fprintln(w, "Judged by money equity")
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tplayer\troll\tluck\tplayed\tbest\tloss\tquality\t")
+	for _, ma := range r.Moves {
+		played, best, loss, quality := ma.PlayedDesc, "", "", ""
+		if played == "" {
+			played = "(no moves)"
+		}
+		if ma.Forced {
+			quality = "forced"
+		} else if ma.EquityLoss > 0 {
+			best, loss = ma.BestDesc, fmt.Sprintf("%.3f", ma.EquityLoss)
+			if ma.Quality != learn.QualityGood.String() {
+				quality = ma.Quality
+			}
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%+.3f\t%s\t%s\t%s\t%s\t\n", ma.Num, ma.Player, ma.Roll, ma.Luck, played, best, loss, quality)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "player\tunforced\tequity lost\tdoubtful\terrors\tblunders\terror rate\tPR\trolls\tluck\t")
+	for _, ps := range r.Players {
+		fmt.Fprintf(tw, "%s\t%d\t%.3f\t%d\t%d\t%d\t%.1f\t%.1f\t%d\t%+.3f\t\n", ps.Player, ps.UnforcedDecisions, ps.TotalEquityLoss,
+			ps.Doubtfuls, ps.Errors, ps.Blunders, ps.ErrorRate, ps.PerformanceRating, ps.Rolls, ps.TotalLuck)
+	}
+	return tw.Flush()
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("JSON Encode error: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/turn"
+	"github.com/seriesoftubes/bgo/game/turngen"
+	"github.com/seriesoftubes/bgo/learn"
+	"github.com/seriesoftubes/bgo/learn/met"
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+func TestAnalyze(t *testing.T) {
+	g, err := game.ImportGame(strings.NewReader("X 31 X;q3;s1\nO 64 O;m4;x6\nX 52 X;l2;l5\nO 11 resign 1 declined\nO 11 O;f1;f1;h1;h1\n"))
+	if err != nil {
+		t.Fatalf("could not import game: %v", err)
+	}
+
+	rep, err := Analyze(nnet.NewUntrainedSet(), g, Options{Ply: 0, LuckPly: 0, Filter: learn.DefaultFilter, Thresholds: learn.DefaultThresholds})
+	if err != nil {
+		t.Fatalf("could not analyze game: %v", err)
+	}
+
+	if len(rep.Moves) != 4 {
+		t.Fatalf("expected the 4 turns to be analyzed, but got %d analyses", len(rep.Moves))
+	}
+	if got := rep.Moves[0].PlayedDesc; got != "8/5 6/5" {
+		t.Errorf("expected the 1st turn to be described as 8/5 6/5 but got %q", got)
+	}
+	for _, ma := range rep.Moves {
+		if ma.EquityLoss < 0 || ma.Forced {
+			t.Errorf("expected an unforced turn with a non-negative equity loss but got %+v", ma)
+		}
+		if ma.EquityLoss == 0 && ma.Best != ma.Played {
+			t.Errorf("expected a turn that loses nothing to be the best turn, but got %+v", ma)
+		}
+	}
+
+	wantDecisions := map[string]int{"X": 2, "O": 2}
+	for _, ps := range rep.Players {
+		if ps.Rolls != wantDecisions[ps.Player] {
+			t.Errorf("expected %s to have the luck of %d rolls rated, but got %d", ps.Player, wantDecisions[ps.Player], ps.Rolls)
+		}
+		if ps.UnforcedDecisions != wantDecisions[ps.Player] {
+			t.Errorf("expected %s to have %d unforced decisions but got %d", ps.Player, wantDecisions[ps.Player], ps.UnforcedDecisions)
+		}
+		wantErrorRate := 1000 * float64(ps.TotalEquityLoss) / float64(ps.UnforcedDecisions)
+		if math.Abs(ps.ErrorRate-wantErrorRate) > 1e-6 || math.Abs(ps.PerformanceRating-wantErrorRate/2) > 1e-6 {
+			t.Errorf("expected %s to have an error rate of %v and a PR of %v but got %v and %v", ps.Player, wantErrorRate, wantErrorRate/2, ps.ErrorRate, ps.PerformanceRating)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := rep.WriteJSON(&buf); err != nil {
+		t.Fatalf("could not write JSON: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded.Moves) != 4 {
+		t.Errorf("could not decode the written JSON: %v", err)
+	}
+}
+
+func TestAnalyzeInMatch(t *testing.T) {
+	g, err := game.ImportGame(strings.NewReader("X 31 X;q3;s1\nO 64 O;m4;x6\nX 52 X;l2;l5\nO 11 O;f1;f1;h1;h1\n"))
+	if err != nil {
+		t.Fatalf("could not import game: %v", err)
+	}
+
+	nets := nnet.NewUntrainedSet()
+	opts := Options{Ply: 0, LuckPly: 0, Filter: learn.DefaultFilter, Thresholds: learn.DefaultThresholds}
+	money, err := Analyze(nets, g, opts)
+	if err != nil {
+		t.Fatalf("could not analyze game: %v", err)
+	}
+	opts.Match = learn.NewMatch(met.Default(), 1) // At double match point, only winning the game matters.
+	match, err := Analyze(nets, g, opts)
+	if err != nil {
+		t.Fatalf("could not analyze game: %v", err)
+	}
+
+	if money.Match != "" || match.Match != opts.Match.String() {
+		t.Errorf("expected only the match analysis to be labeled with the score, but got %q and %q", money.Match, match.Match)
+	}
+	// At double match point, the MWC is the chance of winning the game, and the equity that's worth as much is twice that.
+	b := &game.Board{}
+	b.SetUp()
+	for _, ma := range match.Moves {
+		he := g.History[ma.Num-1]
+		winChance := func(tn turn.Turn) float32 {
+			bcop := b.Copy()
+			bcop.MustExecuteTurn(tn, false)
+			return nets.Evaluate(he.Player.Enemy(), bcop).Flip()[nnet.OutputWin]
+		}
+		var best float32
+		for _, tn := range turngen.ValidTurns(b, he.Roll, he.Player) {
+			if w := winChance(tn); w > best {
+				best = w
+			}
+		}
+		if want := 2 * (best - winChance(he.Turn)); math.Abs(float64(ma.EquityLoss-want)) > 1e-5 {
+			t.Errorf("expected turn #%d to lose %v equivalent equity at DMP but got %v", ma.Num, want, ma.EquityLoss)
+		}
+		b.MustExecuteTurn(he.Turn, false)
+	}
+
+	var buf bytes.Buffer
+	if err := match.WriteTable(&buf); err != nil || !strings.Contains(buf.String(), "match winning chances") {
+		t.Errorf("expected the table to say that turns were judged by match winning chances, but got %v:\n%s", err, buf.String())
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/seriesoftubes/bgo/analysis"
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/learn"
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+const cmdAnalyze = "analyze"
+
+// runAnalyzeCmd analyzes a saved game record, like `./main analyze -record_infile=game.txt -ply=2`.
+func runAnalyzeCmd(args []string) {
+	fs := flag.NewFlagSet(cmdAnalyze, flag.ExitOnError)
+	recordInFilePathPtr := fs.String("record_infile", "", "The file that contains the record of the game to analyze")
+	plyPtr := fs.Int("ply", 1, "How many rolls ahead to look when judging each turn")
+	luckPlyPtr := fs.Int("luck_ply", 0, "How many rolls ahead to look after each possible roll when judging luck")
+	numCandidatesPtr, maxEquityLossPtr := filterFlags(fs)
+	inFilePathPtr := fs.String("config_infile", "", "The file that contains the neural net config to judge the turns with")
+	jsonOutFilePathPtr := fs.String("json_outfile", "", "If set, the file that will contain the analysis as JSON")
+	bearoffDirPtr := bearoffDirFlag(fs)
+	fs.Parse(args)
+
+	if *recordInFilePathPtr == "" {
+		panic("must set -record_infile")
+	}
+	nets := loadNeuralNetworkIfSet(*inFilePathPtr)
+	useBearoffDatabases(nets, *bearoffDirPtr)
+
+	f, err := os.Open(*recordInFilePathPtr)
+	if err != nil {
+		panic("could not open game record: " + err.Error())
+	}
+	defer f.Close()
+
+	g, err := game.ImportGame(f)
+	if err != nil {
+		panic("could not import game record: " + err.Error())
+	}
+	analyzeGame(nets, g, analysis.Options{Ply: *plyPtr, LuckPly: *luckPlyPtr, Filter: learn.Filter{MaxCandidates: *numCandidatesPtr, MaxEquityLoss: float32(*maxEquityLossPtr)}, Thresholds: learn.DefaultThresholds}, *jsonOutFilePathPtr)
+}
+
+// analyzeGame prints `nets`' analysis of every turn in `g`, and also saves it as JSON if `jsonOutFilePath` is set.
+func analyzeGame(nets *nnet.Set, g *game.Game, opts analysis.Options, jsonOutFilePath string) {
+	fmt.Printf("analyzing game at %d-ply...\n", opts.Ply)
+	rep, err := analysis.Analyze(nets, g, opts)
+	if err != nil {
+		panic("could not analyze game: " + err.Error())
+	}
+	if err := rep.WriteTable(os.Stdout); err != nil {
+		panic("could not print analysis: " + err.Error())
+	}
+	fmt.Println("evaluation cache:", nets.CacheStats())
+
+	if jsonOutFilePath != "" {
+		f, err := os.Create(jsonOutFilePath) // always overwrites the existing file.
+		if err != nil {
+			panic("could not create file: " + err.Error())
+		}
+		defer f.Close()
+
+		if err := rep.WriteJSON(f); err != nil {
+			panic("couldnt save analysis: " + err.Error())
+		}
+		fmt.Println("analysis saved to", jsonOutFilePath)
+	}
+}
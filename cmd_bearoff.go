@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/seriesoftubes/bgo/learn/bearoff"
+)
+
+const cmdBearoff = "bearoff"
+
+// A bearoffDatabase is either of the databases that the bearoff command generates.
+type bearoffDatabase interface {
+	Save(w io.Writer) error
+	Verify() error
+}
+
+// runBearoffCmd generates and/or verifies the bearoff databases, like `./main bearoff -generate -verify`.
+func runBearoffCmd(args []string) {
+	fs := flag.NewFlagSet(cmdBearoff, flag.ExitOnError)
+	dirPtr := fs.String("dir", "", "The directory of the databases. Defaults to ~/Desktop/bgo")
+	generatePtr := fs.Bool("generate", false, "Whether to generate the databases and write them to -dir")
+	verifyPtr := fs.Bool("verify", false, "Whether to check the databases in -dir against freshly generated ones")
+	twoSidedCheckersPtr := fs.Int("two_sided_checkers", bearoff.DefaultTwoSidedCheckers, fmt.Sprintf("The most checkers per player that the generated two-sided database covers, up to %d. 0 skips it", bearoff.MaxTwoSidedCheckers))
+	fs.Parse(args)
+
+	if !*generatePtr && !*verifyPtr {
+		panic("must set -generate and/or -verify")
+	}
+	if *twoSidedCheckersPtr < 0 || *twoSidedCheckersPtr > bearoff.MaxTwoSidedCheckers {
+		panic(fmt.Sprintf("-two_sided_checkers must be between 0 and %d, but got %d", bearoff.MaxTwoSidedCheckers, *twoSidedCheckersPtr))
+	}
+	dir := *dirPtr
+	if dir == "" {
+		dir = defaultDir()
+	}
+	oneSidedFilePath, twoSidedFilePath := filepath.Join(dir, bearoff.OneSidedFileName), filepath.Join(dir, bearoff.TwoSidedFileName)
+
+	if *generatePtr {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			panic(fmt.Sprintf("could not create %q: %v", dir, err))
+		}
+		mustGenerateBearoffDatabase(oneSidedFilePath, func() bearoffDatabase { return bearoff.GenerateOneSided(bearoff.MaxOneSidedCheckers) })
+		if *twoSidedCheckersPtr > 0 {
+			mustGenerateBearoffDatabase(twoSidedFilePath, func() bearoffDatabase { return bearoff.GenerateTwoSided(*twoSidedCheckersPtr) })
+		}
+	}
+
+	if *verifyPtr {
+		mustVerifyBearoffDatabase(oneSidedFilePath, func(r io.Reader) (bearoffDatabase, error) { return bearoff.LoadOneSided(r) })
+		mustVerifyBearoffDatabase(twoSidedFilePath, func(r io.Reader) (bearoffDatabase, error) { return bearoff.LoadTwoSided(r) })
+	}
+}
+
+func mustGenerateBearoffDatabase(filePath string, generate func() bearoffDatabase) {
+	start := time.Now()
+	db := generate()
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		panic(fmt.Sprintf("could not create %q: %v", filePath, err))
+	}
+	defer f.Close()
+	if err := db.Save(f); err != nil {
+		panic(fmt.Sprintf("could not write %q: %v", filePath, err))
+	}
+	fmt.Printf("generated %s in %v\n", filePath, time.Since(start))
+}
+
+// mustVerifyBearoffDatabase checks the database in `filePath`, unless there isn't one.
+func mustVerifyBearoffDatabase(filePath string, load func(r io.Reader) (bearoffDatabase, error)) {
+	f, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		fmt.Printf("%s doesn't exist. Skipping.\n", filePath)
+		return
+	} else if err != nil {
+		panic(fmt.Sprintf("could not open %q: %v", filePath, err))
+	}
+	defer f.Close()
+
+	db, err := load(f)
+	if err != nil {
+		panic(fmt.Sprintf("could not load %q: %v", filePath, err))
+	}
+	if err := db.Verify(); err != nil {
+		panic(fmt.Sprintf("%s is wrong: %v", filePath, err))
+	}
+	fmt.Println("verified", filePath)
+}
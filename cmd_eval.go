@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/game/turngen"
+	"github.com/seriesoftubes/bgo/learn"
+	"github.com/seriesoftubes/bgo/learn/cube"
+	"github.com/seriesoftubes/bgo/render"
+)
+
+const cmdEval = "eval"
+
+// runEvalCmd evaluates a single position, like `./main eval -position='a:X2 ...' -player=X -roll=31 -ply=2`.
+func runEvalCmd(args []string) {
+	fs := flag.NewFlagSet(cmdEval, flag.ExitOnError)
+	positionPtr := fs.String("position", "", "The position ID or text (like 'a:X2 f:O5 y:X1') to evaluate. Defaults to the starting position")
+	playerPtr := fs.String("player", plyr.PCC.Symbol(), "The player who's about to roll: 'X' or 'O'")
+	rollPtr := fs.String("roll", "", "If set, the roll (like '31') whose turns get ranked")
+	plyPtr := fs.Int("ply", 0, "How many rolls ahead to look")
+	numTurnsPtr := fs.Int("num_turns", 10, "The max number of ranked turns to print")
+	cubeLifePtr := fs.Float64("cube_life", cube.DefaultCubeLife, "How live the cube is when deciding what to do with it, between 0 (dead) and 1 (fully live)")
+	awayPtr := fs.String("away", "", "If set, the match score to evaluate at, as how many points the player and their opponent are away from winning, like '3-5'. Money otherwise")
+	crawfordPtr := fs.Bool("crawford", false, "Whether the match game is the Crawford game")
+	cubeValuePtr := fs.Int("cube_value", 1, "The value of the cube in a match game")
+	metFilePathPtr := metFlag(fs)
+	inFilePathPtr := fs.String("config_infile", "", "The file that contains the neural net config to evaluate with")
+	bearoffDirPtr := bearoffDirFlag(fs)
+	fs.Parse(args)
+
+	if *plyPtr < 0 || *plyPtr > learn.MaxPly {
+		panic(fmt.Sprintf("ply must be between 0 and %d, but got %d", learn.MaxPly, *plyPtr))
+	}
+	if *playerPtr != plyr.PCC.Symbol() && *playerPtr != plyr.PC.Symbol() {
+		panic(fmt.Sprintf("invalid player %q, should be 'X' or 'O'", *playerPtr))
+	}
+	if *cubeLifePtr < 0 || *cubeLifePtr > 1 {
+		panic(fmt.Sprintf("cube_life must be between 0 and 1, but got %v", *cubeLifePtr))
+	}
+	p := plyr.Player((*playerPtr)[0])
+	var m *learn.Match
+	if *awayPtr != "" {
+		var away, oppAway int
+		if _, err := fmt.Sscanf(*awayPtr, "%d-%d", &away, &oppAway); err != nil || away < 1 || oppAway < 1 {
+			panic(fmt.Sprintf("invalid away %q, should look like '3-5'", *awayPtr))
+		}
+		if *cubeValuePtr < 1 {
+			panic(fmt.Sprintf("cube_value must be at least 1, but got %d", *cubeValuePtr))
+		}
+		m = &learn.Match{Table: mustLoadMatchEquityTable(*metFilePathPtr), Away: map[plyr.Player]int{p: away, p.Enemy(): oppAway}, Crawford: *crawfordPtr}
+	}
+	nets := loadNeuralNetworkIfSet(*inFilePathPtr)
+	useBearoffDatabases(nets, *bearoffDirPtr)
+	defer func() { fmt.Printf("\tEvaluation cache: %v\n", nets.CacheStats()) }()
+
+	b := &game.Board{}
+	b.SetUp()
+	if *positionPtr != "" {
+		var err error
+		if b, err = game.ParsePosition(*positionPtr); err != nil {
+			panic(err.Error())
+		}
+	}
+
+	render.PrintBoard(b)
+	fmt.Println("\tPosition text")
+	fmt.Println("\t\t" + b.PositionText())
+	probs := learn.PositionProbabilities(nets, b, p, *plyPtr)
+	fmt.Printf("\tCubeless equity of %s, who's about to roll: %+.3f (%d-ply)\n", p.Symbol(), probs.Equity(), *plyPtr)
+	fmt.Printf("\t\t%v\n", probs)
+	if m != nil {
+		fmt.Printf("\tMatch winning chance of %s at %v, with the cube at %d: %.1f%%\n", p.Symbol(), m.Score(p), *cubeValuePtr, 100*m.MatchWinningChance(p, probs, *cubeValuePtr))
+	}
+	fmt.Printf("\tCube decisions of %s, with a cube life of %v:\n", p.Symbol(), *cubeLifePtr)
+	for _, c := range []*game.Cube{{Value: uint16(*cubeValuePtr)}, {Value: uint16(*cubeValuePtr), Owner: p}} {
+		owner := "centered"
+		if c.Owner != 0 {
+			owner = "owned by " + p.Symbol()
+		}
+		fmt.Printf("\t\t%s: %v\n", owner, learn.CubeDecision(nets, b, p, c, float32(*cubeLifePtr), *plyPtr, m))
+	}
+
+	if *rollPtr == "" {
+		return
+	}
+	roll, err := game.ParseRoll(*rollPtr)
+	if err != nil {
+		panic(err.Error())
+	}
+	validTurns := turngen.ValidTurns(b, roll, p)
+	if len(validTurns) == 0 {
+		fmt.Printf("\t%s can't move with %s\n", p.Symbol(), *rollPtr)
+		return
+	}
+
+	fmt.Printf("\tBest turns of %s with %s:\n", p.Symbol(), *rollPtr)
+	var ranked []learn.RankedTurn
+	if m != nil {
+		ranked = learn.RankTurnsInMatch(nets, b, validTurns, p, *plyPtr, *numTurnsPtr, m, *cubeValuePtr)
+	} else {
+		ranked = learn.RankTurns(nets, b, validTurns, p, *plyPtr, *numTurnsPtr)
+	}
+	if len(ranked) > *numTurnsPtr {
+		ranked = ranked[:*numTurnsPtr]
+	}
+	format, formatDiff := func(equity float32) string { return fmt.Sprintf("%+.3f", equity) }, func(diff float32) string { return fmt.Sprintf("%+.3f", diff) }
+	if m != nil { // Turns are ranked by their match winning chances.
+		format, formatDiff = func(mwc float32) string { return fmt.Sprintf("%.1f%%", 100*mwc) }, func(diff float32) string { return fmt.Sprintf("%+.1f%%", 100*diff) }
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for i, rt := range ranked {
+		diff := ""
+		if i > 0 {
+			diff = formatDiff(rt.Equity - ranked[0].Equity)
+		}
+		fmt.Fprintf(tw, "\t%d.\t%s\t%v\t%s\t%s\t(%d-ply)\n", i+1, b.Notation(rt.Turn), rt.Turn, format(rt.Equity), diff, rt.Ply)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/seriesoftubes/bgo/analysis"
+	"github.com/seriesoftubes/bgo/ctrl"
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/learn"
+	"github.com/seriesoftubes/bgo/learn/cube"
+)
+
+const cmdPlay = "play"
+
+// runPlayCmd plays one game, like `./main play -x_player=human -o_player=agent:1`.
+func runPlayCmd(args []string) {
+	fs := flag.NewFlagSet(cmdPlay, flag.ExitOnError)
+	inFilePathPtr := fs.String("config_infile", "", "The file that contains the neural net config that agents play with")
+	xPlayerPtr := fs.String("x_player", ctrl.PlayerSpecHuman, "Who plays as X: 'human', 'random', 'pubeval', 'agent:<ply>[:<weights file>]' or 'script:<path>'")
+	oPlayerPtr := fs.String("o_player", ctrl.PlayerSpecAgent, "Who plays as O: 'human', 'random', 'pubeval', 'agent:<ply>[:<weights file>]' or 'script:<path>'")
+	reservePtr := fs.Duration("reserve", 0, "If set, the time each player gets for the whole game, like 10m")
+	delayPtr := fs.Duration("delay", 0, "The per-move delay when the game is timed, like 12s")
+	delayKindPtr := fs.String("delay_kind", "bronstein", "The kind of per-move delay when the game is timed: 'none', 'simple' or 'bronstein'")
+	tutorPtr := fs.String("tutor", "", "If set, warns human players before they play a turn that's at least this bad: 'doubtful', 'error' or 'blunder'")
+	tutorPlyPtr := fs.Int("tutor_ply", 1, "How many rolls ahead the tutor looks when judging turns")
+	analyzePtr := fs.Bool("analyze", false, "Whether to analyze every turn of the game once it's over")
+	analyzePlyPtr := fs.Int("analyze_ply", 1, "How many rolls ahead the analysis looks when judging turns")
+	analyzeLuckPlyPtr := fs.Int("analyze_luck_ply", 0, "How many rolls ahead the analysis looks after each possible roll when judging luck")
+	analysisOutFilePtr := fs.String("analysis_outfile", "", "If set, the file that will contain the analysis as JSON. In a match, each game gets its own file, numbered like analysis_1.json")
+	recordOutFilePathPtr := fs.String("record_outfile", "", "If set, the file that will contain the record of the game. In a match, each game gets its own file, numbered like game_1.txt")
+	cubePtr := fs.Bool("cube", false, "Whether to play with the doubling cube")
+	cubeLifePtr := fs.Float64("cube_life", cube.DefaultCubeLife, "How live agents and cube hints assume the cube to be, between 0 (dead) and 1 (fully live)")
+	matchLengthPtr := fs.Int("match_length", 0, "If set, plays a match to this many points with the doubling cube, instead of a single game")
+	metFilePathPtr := metFlag(fs)
+	bearoffDirPtr := bearoffDirFlag(fs)
+	fs.Parse(args)
+
+	nets := loadNeuralNetworkIfExists(filePathFromFlag(inFilePathPtr))
+	useBearoffDatabases(nets, *bearoffDirPtr)
+
+	if *cubeLifePtr < 0 || *cubeLifePtr > 1 {
+		panic(fmt.Sprintf("cube_life must be between 0 and 1, but got %v", *cubeLifePtr))
+	}
+
+	mgr := ctrl.New(true /* debug=true*/, nets)
+	if *cubePtr {
+		mgr.UseCube()
+	}
+	for p, spec := range map[plyr.Player]string{plyr.PCC: *xPlayerPtr, plyr.PC: *oPlayerPtr} {
+		pl, err := ctrl.NewPlayerFromSpec(spec, nets)
+		if err != nil {
+			panic(err.Error())
+		}
+		if hp, isHuman := pl.(*ctrl.HumanPlayer); isHuman && *tutorPtr != "" {
+			minQuality, err := learn.ParseMoveQuality(*tutorPtr)
+			if err != nil {
+				panic(err.Error())
+			}
+			hp.SetTutor(ctrl.NewTutor(nets, minQuality, *tutorPlyPtr))
+		}
+		switch pl := pl.(type) {
+		case *ctrl.HumanPlayer:
+			pl.SetCubeLife(float32(*cubeLifePtr))
+		case *ctrl.AgentPlayer:
+			pl.SetCubeLife(float32(*cubeLifePtr))
+		}
+		mgr.SetPlayer(p, pl)
+	}
+	if *reservePtr > 0 {
+		delayKind, err := game.ParseDelayKind(*delayKindPtr)
+		if err != nil {
+			panic(err.Error())
+		}
+		mgr.SetTimeControl(game.TimeControl{Reserve: *reservePtr, Delay: *delayPtr, DelayKind: delayKind})
+	}
+	ctx, cancel := signalContext()
+	defer cancel()
+	var games []ctrl.MatchGame
+	if *matchLengthPtr > 0 {
+		mgr.PlayMatch(ctx, *matchLengthPtr, mustLoadMatchEquityTable(*metFilePathPtr))
+		games = mgr.MatchGames()
+	} else {
+		mgr.PlayOneGame(ctx, true /* stopLearning=true */)
+		games = []ctrl.MatchGame{{Game: mgr.MostRecentGame()}} // A single game isn't part of a match, so it's judged by money equity.
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	for i, mg := range games {
+		recordOutFilePath, analysisOutFilePath := *recordOutFilePathPtr, *analysisOutFilePtr
+		if *matchLengthPtr > 0 {
+			recordOutFilePath, analysisOutFilePath = numberedFilePath(recordOutFilePath, i+1), numberedFilePath(analysisOutFilePath, i+1)
+			fmt.Printf("game %d of the match:\n", i+1)
+		}
+		if recordOutFilePath != "" {
+			saveGameRecord(mg.Game, recordOutFilePath)
+		}
+		if *analyzePtr {
+			opts := analysis.Options{Ply: *analyzePlyPtr, LuckPly: *analyzeLuckPlyPtr, Filter: learn.DefaultFilter, Thresholds: learn.DefaultThresholds, Match: mg.Match}
+			analyzeGame(nets, mg.Game, opts, analysisOutFilePath)
+		}
+	}
+}
+
+// numberedFilePath returns `filePath` with `n` added before its extension, like game_1.txt for game.txt, or "" if it's empty.
+func numberedFilePath(filePath string, n int) string {
+	if filePath == "" {
+		return ""
+	}
+	ext := filepath.Ext(filePath)
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(filePath, ext), n, ext)
+}
+
+func saveGameRecord(g *game.Game, filePath string) {
+	fmt.Println("saving game record to", filePath)
+
+	f, err := os.Create(filePath) // always overwrites the existing file.
+	if err != nil {
+		panic("could not create file: " + err.Error())
+	}
+	defer f.Close()
+
+	if err := g.Export(f); err != nil {
+		panic("couldnt save game record: " + err.Error())
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+
+	"github.com/seriesoftubes/bgo/server"
+)
+
+const cmdServe = "serve"
+
+// runServeCmd serves position evaluations over HTTP, like `./main serve -addr=:8080`.
+func runServeCmd(args []string) {
+	fs := flag.NewFlagSet(cmdServe, flag.ExitOnError)
+	addrPtr := fs.String("addr", ":8080", "The address to listen on")
+	inFilePathPtr := fs.String("config_infile", "", "The file that contains the neural net config to evaluate with. If empty, an untrained neural net is used")
+	bearoffDirPtr := bearoffDirFlag(fs)
+	fs.Parse(args)
+
+	nets := loadNeuralNetworkIfSet(*inFilePathPtr)
+	useBearoffDatabases(nets, *bearoffDirPtr)
+
+	fmt.Printf("serving evaluations on %s%s\n", *addrPtr, server.PathEval)
+	if err := http.ListenAndServe(*addrPtr, server.NewHandler(nets)); err != nil {
+		panic("server failed: " + err.Error())
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/seriesoftubes/bgo/ctrl"
+	"github.com/seriesoftubes/bgo/tournament"
+)
+
+const cmdTournament = "tournament"
+
+// runTournamentCmd plays a round-robin between bots, like `./main tournament -entrants=random,agent:0,agent:1 -games_per_pairing=1000`.
+func runTournamentCmd(args []string) {
+	fs := flag.NewFlagSet(cmdTournament, flag.ExitOnError)
+	entrantsPtr := fs.String("entrants", "random,agent:0", "Comma-separated specs of the bots to enter: 'random', 'pubeval', 'agent:<ply>[:<weights file>]' or 'script:<path>'")
+	gamesPerPairingPtr := fs.Int("games_per_pairing", 100, "The number of games that each pair of entrants plays against each other")
+	numGoroutinesPtr := fs.Int("goroutines", runtime.NumCPU(), "The number of goroutines to play games on")
+	inFilePathPtr := fs.String("config_infile", "", "The file that contains the neural net config that every agent uses, unless its spec names a weights file")
+	jsonOutFilePathPtr := fs.String("json_outfile", "", "If set, the file that will contain the results as JSON")
+	duplicatePtr := fs.Bool("duplicate", false, "Whether to play a duplicate dice match between exactly 2 entrants, where each pair of games uses the same dice with the seats swapped")
+	seedPtr := fs.Int64("seed", time.Now().UnixNano(), "The seed of the first pair's dice in a duplicate dice match")
+	bearoffDirPtr := bearoffDirFlag(fs)
+	fs.Parse(args)
+
+	nets := loadNeuralNetworkIfSet(*inFilePathPtr)
+	useBearoffDatabases(nets, *bearoffDirPtr)
+
+	var entrants []tournament.Entrant
+	for _, spec := range strings.Split(*entrantsPtr, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == ctrl.PlayerSpecHuman {
+			panic("humans can't enter tournaments")
+		}
+		if _, err := ctrl.NewPlayerFromSpec(spec, nets); err != nil {
+			panic(err.Error())
+		}
+		entrants = append(entrants, tournament.Entrant{Name: spec, NewPlayer: func() (ctrl.Player, error) { return ctrl.NewPlayerFromSpec(spec, nets) }})
+	}
+
+	var res interface {
+		WriteTable(io.Writer) error
+		WriteJSON(io.Writer) error
+	}
+	var err error
+	if *duplicatePtr {
+		if len(entrants) != 2 {
+			panic(fmt.Sprintf("a duplicate dice match needs exactly 2 entrants, but got %d", len(entrants)))
+		}
+		numPairs := *gamesPerPairingPtr / 2
+		fmt.Printf("playing %d pairs of duplicate dice games with seed %d...\n", numPairs, *seedPtr)
+		res, err = tournament.RunDuplicate(entrants[0], entrants[1], numPairs, *numGoroutinesPtr, *seedPtr)
+	} else {
+		fmt.Printf("playing %d games per pairing between %d entrants...\n", *gamesPerPairingPtr, len(entrants))
+		res, err = tournament.Run(entrants, *gamesPerPairingPtr, *numGoroutinesPtr)
+	}
+	if err != nil {
+		panic("tournament failed: " + err.Error())
+	}
+	if err := res.WriteTable(os.Stdout); err != nil {
+		panic("could not print results: " + err.Error())
+	}
+
+	if *jsonOutFilePathPtr != "" {
+		f, err := os.Create(*jsonOutFilePathPtr) // always overwrites the existing file.
+		if err != nil {
+			panic("could not create file: " + err.Error())
+		}
+		defer f.Close()
+
+		if err := res.WriteJSON(f); err != nil {
+			panic("couldnt save results: " + err.Error())
+		}
+		fmt.Println("results saved to", *jsonOutFilePathPtr)
+	}
+}
@@ -0,0 +1,671 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/seriesoftubes/bgo/ctrl"
+	"github.com/seriesoftubes/bgo/learn/checkpoint"
+	"github.com/seriesoftubes/bgo/learn/nnet"
+	"github.com/seriesoftubes/bgo/learn/nnet/nnperf"
+	"github.com/seriesoftubes/bgo/learn/trainconf"
+	"github.com/seriesoftubes/bgo/state"
+)
+
+const (
+	cmdTrain = "train"
+
+	cmdAverageVariance                           = "avgvar"
+	cmdTotalVariance                             = "ttlvar"
+	cmdCFG                                       = "cfg"
+	cmdprefixMultiplyLearningRate                = "mulr_"
+	cmdprefixChangeLearningRateReducerInterval   = "interval_"
+	cmdprefixChangeLearningRateReducerMultiplier = "multiplier_"
+	cmdHelp                                      = "help"
+)
+
+type (
+	learningRateManager struct {
+		sync.RWMutex
+		interval   uint64  // Every `interval` games, we update the learningRate
+		multiplier float32 // Every `interval` games, we multiply the learningRate by `multiplier`
+	}
+
+	// Gotta train'em all! Poke-model!
+	pokemodelTrainer struct {
+		cfg                           *trainconf.Config
+		nets                          *nnet.Set
+		varianceLogsFilePath          string
+		hasLoadedNN                   bool
+		startGamesPlayed, gamesPlayed uint64
+		lrManager                     *learningRateManager
+		// Games get played while holding a read lock, so that a checkpoint can hold the write lock to save a consistent state between games.
+		pauseMu           sync.RWMutex
+		checkpointDueChan chan bool
+		lastCheckpointAt  uint64 // The game counter of the latest checkpoint. Guarded by pauseMu.
+	}
+)
+
+func newTrainer(cfg *trainconf.Config) *pokemodelTrainer {
+	varianceLogsFilePath := strings.Replace(cfg.WeightsOutFile, ".json", "_variance_report.txt", 1)
+	return &pokemodelTrainer{
+		cfg:                  cfg,
+		nets:                 nnet.NewSet(newNetwork(cfg.Architecture)),
+		varianceLogsFilePath: varianceLogsFilePath,
+		lrManager:            &learningRateManager{interval: cfg.LearningRateReductionInterval, multiplier: cfg.LearningRateReductionMultiplier},
+		checkpointDueChan:    make(chan bool, 1),
+	}
+}
+
+// newNetwork creates an untrained network with the given architecture, or with the default one if it's nil.
+func newNetwork(arch *nnet.Architecture) *nnet.Network {
+	if arch == nil {
+		return nnet.New()
+	}
+	net, err := nnet.NewWithArchitecture(*arch)
+	if err != nil {
+		panic(err.Error())
+	}
+	return net
+}
+
+func onHelpCmd() {
+	fmt.Println("valid commands are:")
+	fmt.Println("'d' or 'r' to repeat the previous command")
+	fmt.Println(cmdAverageVariance)
+	fmt.Println(cmdTotalVariance)
+	fmt.Println(cmdCFG)
+	fmt.Println(cmdprefixMultiplyLearningRate, "(plus a number, like mulr_1.23)")
+	fmt.Println(cmdprefixChangeLearningRateReducerInterval, "(plus a number like bla_123123)")
+	fmt.Println(cmdprefixChangeLearningRateReducerMultiplier, "(plus a number like bla_0.8")
+	fmt.Println(cmdHelp)
+}
+
+func onMulrCmd(nets *nnet.Set, cmd string) {
+	factor, err := float32FromCommand(cmd)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	fmt.Println("multiplying learning rate by", factor)
+	nets.MultiplyLearningRate(factor)
+}
+
+func float32FromCommand(cmd string) (float32, error) {
+	split := strings.Split(cmd, "_")
+	if len(split) != 2 {
+		return 0, fmt.Errorf("invalid command %q, should look like 'blabla_5.5'", cmd)
+	}
+
+	factor, err := strconv.ParseFloat(split[1], 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %s in command %q: %v", split[1], cmd, err)
+	}
+
+	return float32(factor), nil
+}
+
+func (lrm *learningRateManager) maybeChangeLearningRate(nets *nnet.Set, numGamesCompleted uint64) {
+	if my_multiplier, my_interval := lrm.params(); numGamesCompleted%my_interval == 0 {
+		fmt.Println("multiplying the neural nets' learning rate by", my_multiplier)
+		nets.MultiplyLearningRate(my_multiplier)
+	}
+}
+
+func (lrm *learningRateManager) setInterval(newInterval uint64) {
+	defer lrm.Unlock()
+	lrm.Lock()
+
+	fmt.Println("setting learningRateReducerInterval to", newInterval)
+	lrm.interval = newInterval
+}
+
+func (lrm *learningRateManager) setMultiplier(newMultiplier float32) {
+	defer lrm.Unlock()
+	lrm.Lock()
+
+	fmt.Println("setting learningRateReductionMultiplier to", newMultiplier)
+	lrm.multiplier = newMultiplier
+}
+
+func (lrm *learningRateManager) params() (float32, uint64) {
+	defer lrm.RUnlock()
+	lrm.RLock()
+	return lrm.multiplier, lrm.interval
+}
+
+func (pt *pokemodelTrainer) onCfgCmd() {
+	learningRate, decayRate := pt.nets.Net(state.ClassContact).LearningParams()
+	multiplier, interval := pt.lrManager.params()
+
+	fmt.Println("learningRate", learningRate)
+	fmt.Println("decayRate", decayRate)
+	fmt.Println("learningRateReductionMultiplier", multiplier)
+	fmt.Println("learningRateReductionInterval", interval)
+	fmt.Println("gamesPlayed", atomic.LoadUint64(&pt.gamesPlayed))
+	fmt.Println("evaluationCache", pt.nets.CacheStats())
+}
+
+func (pt *pokemodelTrainer) onGameCompleted() {
+	atomic.AddUint64(&pt.gamesPlayed, 1)
+	ct := atomic.LoadUint64(&pt.gamesPlayed)
+	if ct%500 == 0 {
+		fmt.Println(time.Now(), "trained on", ct, "games")
+	}
+	pt.lrManager.maybeChangeLearningRate(pt.nets, ct)
+
+	if every := pt.cfg.CheckpointEveryGames; every > 0 && (ct-atomic.LoadUint64(&pt.startGamesPlayed))%every == 0 {
+		select {
+		case pt.checkpointDueChan <- true:
+		default: // A checkpoint is already due.
+		}
+	}
+}
+
+func (pt *pokemodelTrainer) loadNeuralNetwork() {
+	filePath := pt.cfg.WeightsInFile
+	fmt.Println("loading neural network config from", filePath)
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		fmt.Printf("could not open neural network file %q. Skipping. %v\n", filePath, err)
+		pt.hasLoadedNN = true
+		return
+	}
+	defer f.Close()
+
+	if existingGamesPlayed, err := pt.nets.Net(state.ClassContact).Load(f); err != nil {
+		panic("could not deserialize neural network: " + err.Error())
+	} else {
+		atomic.StoreUint64(&pt.gamesPlayed, existingGamesPlayed)
+		atomic.StoreUint64(&pt.startGamesPlayed, existingGamesPlayed)
+	}
+
+	pt.hasLoadedNN = true
+	fmt.Println("neural net loaded!")
+}
+
+// addClassNetworks gives every position class that the config lists its own network, unless it already has one.
+// A class's network is loaded from next to the input weights if it's there, or else it starts out as a copy of the contact network.
+// Then the classes that end up with their own network get recorded in the config.
+func (pt *pokemodelTrainer) addClassNetworks() {
+	for _, name := range pt.cfg.ClassNetworks {
+		c := mustParseClass(name)
+		if pt.nets.HasOwnNet(c) {
+			continue
+		}
+
+		filePath := nnet.ClassFilePath(pt.cfg.WeightsInFile, c)
+		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+			fmt.Printf("the %v neural net starts out as a copy of the contact one, since %q doesn't exist\n", c, filePath)
+			pt.nets.SetNet(c, pt.nets.Net(state.ClassContact).Clone())
+			continue
+		}
+		fmt.Printf("loading the %v neural net from %s\n", c, filePath)
+		net, _, err := nnet.LoadFile(filePath)
+		if err != nil {
+			panic(err.Error())
+		}
+		pt.nets.SetNet(c, net)
+	}
+
+	pt.cfg.ClassNetworks = []string{}
+	for c := state.ClassContact + 1; c < state.NumClasses; c++ {
+		if pt.nets.HasOwnNet(c) {
+			pt.cfg.ClassNetworks = append(pt.cfg.ClassNetworks, c.String())
+		}
+	}
+}
+
+func mustParseClass(name string) state.Class {
+	c, err := state.ParseClass(name)
+	if err != nil {
+		panic(err.Error())
+	}
+	return c
+}
+
+// resumeFromCheckpoint restores the weights, learning params, game counters and metrics of the latest checkpoint.
+func (pt *pokemodelTrainer) resumeFromCheckpoint(c *checkpoint.Checkpoint) {
+	if _, err := pt.nets.Net(state.ClassContact).Load(bytes.NewReader(c.Network)); err != nil {
+		panic("could not deserialize the checkpoint's neural network: " + err.Error())
+	}
+	for name, network := range c.ClassNetworks {
+		net := nnet.New()
+		if _, err := net.Load(bytes.NewReader(network)); err != nil {
+			panic(fmt.Sprintf("could not deserialize the checkpoint's %s neural network: %v", name, err))
+		}
+		pt.nets.SetNet(mustParseClass(name), net)
+	}
+	atomic.StoreUint64(&pt.gamesPlayed, c.GamesPlayed)
+	atomic.StoreUint64(&pt.startGamesPlayed, c.StartGamesPlayed)
+	pt.lastCheckpointAt = c.GamesPlayed
+	pt.lrManager.setInterval(c.LearningRateReductionInterval)
+	pt.lrManager.setMultiplier(c.LearningRateReductionMultiplier)
+	nnperf.Restore(c.AverageVariances, c.TotalVariances)
+
+	pt.hasLoadedNN = true
+	fmt.Printf("resumed after %d of %d games\n", c.GamesPlayed-c.StartGamesPlayed, pt.cfg.TotalGamesToPlay)
+}
+
+// saveCheckpoint waits for the games in progress to finish, then saves the state of the run.
+// A failed checkpoint is reported, but doesn't stop the training.
+func (pt *pokemodelTrainer) saveCheckpoint() {
+	pt.pauseMu.Lock()
+	defer pt.pauseMu.Unlock()
+
+	gamesPlayed := atomic.LoadUint64(&pt.gamesPlayed)
+	if gamesPlayed == pt.lastCheckpointAt {
+		return // Nothing changed since the latest checkpoint.
+	}
+
+	var contactNetwork json.RawMessage
+	classNetworks := map[string]json.RawMessage{}
+	for class := state.ClassContact; class < state.NumClasses; class++ {
+		if !pt.nets.HasOwnNet(class) {
+			continue
+		}
+		var network bytes.Buffer
+		if err := pt.nets.Net(class).Save(&network, gamesPlayed, true /* waitForWrites=true*/); err != nil {
+			fmt.Println("could not save checkpoint:", err)
+			return
+		}
+		if class == state.ClassContact {
+			contactNetwork = network.Bytes()
+		} else {
+			classNetworks[class.String()] = network.Bytes()
+		}
+	}
+	multiplier, interval := pt.lrManager.params()
+	c := &checkpoint.Checkpoint{
+		SavedAt:                         time.Now(),
+		Config:                          pt.cfg,
+		StartGamesPlayed:                atomic.LoadUint64(&pt.startGamesPlayed),
+		GamesPlayed:                     gamesPlayed,
+		LearningRateReductionInterval:   interval,
+		LearningRateReductionMultiplier: multiplier,
+		AverageVariances:                nnperf.GameAverageVariances(-1, true),
+		TotalVariances:                  nnperf.GameTotalVariances(-1, true),
+		Network:                         contactNetwork,
+		ClassNetworks:                   classNetworks,
+	}
+
+	filePath, err := checkpoint.Save(pt.cfg.WeightsOutFile, c, pt.cfg.CheckpointsToKeep)
+	if err != nil {
+		fmt.Println("could not save checkpoint:", err)
+		return
+	}
+	pt.lastCheckpointAt = gamesPlayed
+	fmt.Println(time.Now(), "saved checkpoint to", filePath)
+}
+
+func (pt *pokemodelTrainer) checkpointPeriodically(doneChan chan bool) {
+	var tickChan <-chan time.Time
+	if mins := pt.cfg.CheckpointEveryMinutes; mins > 0 {
+		ticker := time.NewTicker(time.Duration(mins * float64(time.Minute)))
+		defer ticker.Stop()
+		tickChan = ticker.C
+	}
+
+	for {
+		select {
+		case <-doneChan:
+			return
+		case <-pt.checkpointDueChan:
+			pt.saveCheckpoint()
+		case <-tickChan:
+			pt.saveCheckpoint()
+		}
+	}
+}
+
+// applyLearningParams overrides the learning params that were loaded with the weights by the ones that the config sets,
+// and records the ones that the contact network ends up using in the config.
+func (pt *pokemodelTrainer) applyLearningParams() {
+	for _, net := range pt.nets.Networks() {
+		learningRate, decayRate := net.LearningParams()
+		if pt.cfg.LearningRate > 0 {
+			learningRate = pt.cfg.LearningRate
+		}
+		if pt.cfg.EligibilityDecayRate > 0 {
+			decayRate = pt.cfg.EligibilityDecayRate
+		}
+		net.SetLearningParams(learningRate, decayRate)
+	}
+	pt.cfg.LearningRate, pt.cfg.EligibilityDecayRate = pt.nets.Net(state.ClassContact).LearningParams()
+}
+
+// applyArchitecture checks that every network that was loaded has the architecture that the config asks for,
+// and records the one that ends up being used in the config.
+func (pt *pokemodelTrainer) applyArchitecture() {
+	arch := pt.nets.Net(state.ClassContact).Architecture()
+	if pt.cfg.Architecture != nil {
+		arch = *pt.cfg.Architecture
+	}
+	for c := state.ClassContact; c < state.NumClasses; c++ {
+		if netArch := pt.nets.Net(c).Architecture(); !netArch.Equal(arch) {
+			panic(fmt.Sprintf("the run needs hidden layers %q, but the loaded %v neural net has %q", arch, c, netArch))
+		}
+	}
+	pt.cfg.Architecture = &arch
+}
+
+// saveConfig copies the config of this run next to the output weights, so that the run can be told apart and repeated.
+func (pt *pokemodelTrainer) saveConfig() {
+	filePath := trainconf.FilePathNextTo(pt.cfg.WeightsOutFile)
+	fmt.Println("saving training config to", filePath)
+
+	if err := checkpoint.WriteFileAtomically(filePath, pt.cfg.Save); err != nil {
+		panic("couldnt save training config: " + err.Error())
+	}
+}
+
+// saveNeuralNetwork saves the contact network to the output weights file, and the network of every other class that has its own next to it.
+func (pt *pokemodelTrainer) saveNeuralNetwork(waitForWrites bool) {
+	for c := state.ClassContact; c < state.NumClasses; c++ {
+		if !pt.nets.HasOwnNet(c) {
+			continue
+		}
+		filePath := nnet.ClassFilePath(pt.cfg.WeightsOutFile, c)
+		fmt.Println("saving neural net config to", filePath)
+
+		err := checkpoint.WriteFileAtomically(filePath, func(w io.Writer) error {
+			return pt.nets.Net(c).Save(w, atomic.LoadUint64(&pt.gamesPlayed), waitForWrites)
+		})
+		if err != nil {
+			panic("couldnt save neural network: " + err.Error())
+		}
+	}
+
+	fmt.Println("neural net config saved!")
+}
+
+func (pt *pokemodelTrainer) writeVarianceLogs(waitForWrites bool) {
+	filePath := pt.varianceLogsFilePath
+	fmt.Println("saving variance data to", filePath)
+
+	f, err := os.Create(filePath) // always overwrites the existing file.
+	if err != nil {
+		panic("could not create file: " + err.Error())
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	writeLine := func(ln string) {
+		if _, err = w.WriteString(ln); err != nil {
+			panic(fmt.Sprintf("w.WriteString(%q) error: %v", ln, err))
+		}
+	}
+
+	writeLine("GamesPlayed\tAvgVariance\n")
+	startGamesPlayed := atomic.LoadUint64(&pt.startGamesPlayed)
+	for i, v := range nnperf.GameAverageVariances(-1, waitForWrites) {
+		writeLine(fmt.Sprintf("%v\t%v\n", uint64(i)+1+startGamesPlayed, v))
+		if i%1000 == 0 {
+			w.Flush()
+		}
+	}
+	w.Flush()
+
+	fmt.Println("done saving variance data!")
+}
+
+func (pt *pokemodelTrainer) onChangeLearningRateReducerIntervalCmd(cmd string) {
+	newInterval, err := float32FromCommand(cmd)
+	if err != nil {
+		fmt.Println("could not parse number from cmd", cmd, err.Error())
+		return
+	}
+	pt.lrManager.setInterval(uint64(newInterval))
+}
+
+func (pt *pokemodelTrainer) onChangeLearningRateReducerMultiplierCmd(cmd string) {
+	newMultiplier, err := float32FromCommand(cmd)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	pt.lrManager.setMultiplier(newMultiplier)
+}
+
+// TODO: split this into its own struct.
+func (pt *pokemodelTrainer) readCommands(doneChan chan bool) {
+	var previousCmd string
+
+	for {
+		select {
+		case <-doneChan:
+			fmt.Println("done reading model training commands")
+			return
+		default:
+			fmt.Print("\n\nenter a command\n\n")
+		}
+
+		var rawCmd string
+		fmt.Scanln(&rawCmd)
+		cmd := strings.ToLower(strings.TrimSpace(rawCmd))
+		if cmd == "" {
+			continue
+		}
+
+		cmdWasToRepeatPreviousCommand := cmd == "d" || cmd == "r"
+		if cmdWasToRepeatPreviousCommand {
+			fmt.Printf("repeating command: %q\n", previousCmd)
+			cmd = previousCmd
+		}
+
+		if cmd == cmdAverageVariance {
+			for _, v := range nnperf.GameAverageVariances(30, false) {
+				fmt.Println(v)
+			}
+		} else if cmd == cmdTotalVariance {
+			for _, v := range nnperf.GameTotalVariances(30, false) {
+				fmt.Println(v)
+			}
+		} else if cmd == cmdCFG {
+			pt.onCfgCmd()
+		} else if strings.HasPrefix(cmd, cmdprefixMultiplyLearningRate) {
+			onMulrCmd(pt.nets, cmd)
+		} else if strings.HasPrefix(cmd, cmdprefixChangeLearningRateReducerInterval) {
+			pt.onChangeLearningRateReducerIntervalCmd(cmd)
+		} else if strings.HasPrefix(cmd, cmdprefixChangeLearningRateReducerMultiplier) {
+			pt.onChangeLearningRateReducerMultiplierCmd(cmd)
+		} else if cmd == cmdHelp {
+			onHelpCmd()
+		} else {
+			fmt.Println("unrecognized command", cmd)
+			continue
+		}
+
+		if !cmdWasToRepeatPreviousCommand {
+			previousCmd = cmd
+		}
+	}
+}
+
+// train plays the rest of the run's games, or stops early once `ctx` is canceled. The games in progress get abandoned, and the
+// state of the run gets checkpointed so that it can be resumed.
+func (pt *pokemodelTrainer) train(ctx context.Context) {
+	if !pt.hasLoadedNN {
+		pt.loadNeuralNetwork()
+	}
+
+	numGoroutines, gamesPlayedBefore := pt.cfg.Goroutines, atomic.LoadUint64(&pt.gamesPlayed)-atomic.LoadUint64(&pt.startGamesPlayed)
+	var gamesToPlay uint64
+	if gamesPlayedBefore < pt.cfg.TotalGamesToPlay {
+		gamesToPlay = pt.cfg.TotalGamesToPlay - gamesPlayedBefore
+	}
+	fmt.Printf("training on %d games across %d goroutines...\n", gamesToPlay, numGoroutines)
+	start := time.Now()
+
+	doneChan, checkpointDoneChan := make(chan bool, 1), make(chan bool)
+	go pt.readCommands(doneChan)
+	go pt.checkpointPeriodically(checkpointDoneChan)
+
+	var gamesStarted uint64
+	var wg sync.WaitGroup
+	wg.Add(int(numGoroutines))
+	for i := uint64(0); i < numGoroutines; i++ {
+		go func() {
+			mgr := ctrl.NewWithEpsilon(false, pt.nets, pt.cfg.Epsilon)
+			for ctx.Err() == nil && atomic.AddUint64(&gamesStarted, 1) <= gamesToPlay {
+				pt.pauseMu.RLock()
+				mgr.PlayOneGame(ctx, false) // Play 1 game against itself and don't stop learning!
+				if ctx.Err() == nil {
+					mgr.TransmitStatsFromMostRecentGame()
+					mgr.WaitForStats() // So that a checkpoint's metrics match its game counter.
+					pt.onGameCompleted()
+				}
+				pt.pauseMu.RUnlock()
+			}
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+
+	checkpointDoneChan <- true
+	if ctx.Err() != nil {
+		fmt.Println("training was stopped early")
+		pt.saveCheckpoint()
+	}
+	doneChan <- true
+	close(doneChan)
+	fmt.Printf("trained %d times in %v\n", atomic.LoadUint64(&pt.gamesPlayed)-atomic.LoadUint64(&pt.startGamesPlayed), time.Since(start))
+}
+
+// runTrainCmd trains the neural network by having it play against itself, like `./main train -train_config=run.json -epsilon=0.3`.
+// The run is described by the defaults, overridden by the -train_config file, overridden by whichever flags are set.
+func runTrainCmd(args []string) {
+	defaults := trainconf.Default()
+	fs := flag.NewFlagSet(cmdTrain, flag.ExitOnError)
+	trainConfigPtr := fs.String("train_config", "", "If set, the JSON file that describes the training run. Flags that are set override it")
+	totalGamesToPlayPtr := fs.Uint64("total_games_to_play", defaults.TotalGamesToPlay, "The total number of games to play across all goroutines")
+	numGoroutinesPtr := fs.Uint64("goroutines", defaults.Goroutines, "The number of goroutines to run on")
+	epsilonPtr := fs.Float64("epsilon", float64(defaults.Epsilon), "The chance (number between 0 and 1.0) that an agent picks a random move instead of an optimal one")
+	learningRatePtr := fs.Float64("learning_rate", 0, "If positive, overrides the learning rate that was saved with the neural net config")
+	decayRatePtr := fs.Float64("eligibility_decay_rate", 0, "If positive, overrides the eligibility trace decay rate that was saved with the neural net config")
+	intervalPtr := fs.Uint64("learning_rate_reduction_interval", defaults.LearningRateReductionInterval, "Every this many games, the learning rate gets reduced")
+	multiplierPtr := fs.Float64("learning_rate_reduction_multiplier", float64(defaults.LearningRateReductionMultiplier), "What the learning rate gets multiplied by when it's reduced")
+	hiddenLayersPtr := fs.String("hidden_layers", "", "If set, the hidden layers of a new neural net, like '128:tanh,64:relu'. Activations are 'tanh', 'relu', 'sigmoid' or 'linear'")
+	classNetworksPtr := fs.String("class_networks", strings.Join(defaults.ClassNetworks, ","), "The position classes besides 'contact' that get their own neural net, like 'crashed,race,bearoff'. The rest share the contact neural net")
+	inFilePathPtr := fs.String("config_infile", "", "The file that contains the initial neural net config")
+	outFilePathPtr := fs.String("config_outfile", "", "The file that will contain the updated neural net config")
+	checkpointGamesPtr := fs.Uint64("checkpoint_every_games", defaults.CheckpointEveryGames, "Save a checkpoint every this many games. 0 turns it off")
+	checkpointMinutesPtr := fs.Float64("checkpoint_every_minutes", defaults.CheckpointEveryMinutes, "Save a checkpoint every this many minutes. 0 turns it off")
+	checkpointsToKeepPtr := fs.Int("checkpoints_to_keep", defaults.CheckpointsToKeep, "How many of the newest checkpoints to keep")
+	resumePtr := fs.Bool("resume", false, "Whether to continue the run from the latest checkpoint next to -config_outfile. Flags that are set override the checkpoint's training config")
+	bearoffDirPtr := bearoffDirFlag(fs)
+	fs.Parse(args)
+
+	cfg := defaults
+	if *trainConfigPtr != "" {
+		cfg = mustLoadTrainConfig(*trainConfigPtr)
+	}
+	applyFlags := func(f *flag.Flag) {
+		switch f.Name {
+		case "total_games_to_play":
+			cfg.TotalGamesToPlay = *totalGamesToPlayPtr
+		case "goroutines":
+			cfg.Goroutines = *numGoroutinesPtr
+		case "epsilon":
+			cfg.Epsilon = float32(*epsilonPtr)
+		case "learning_rate":
+			cfg.LearningRate = float32(*learningRatePtr)
+		case "eligibility_decay_rate":
+			cfg.EligibilityDecayRate = float32(*decayRatePtr)
+		case "learning_rate_reduction_interval":
+			cfg.LearningRateReductionInterval = *intervalPtr
+		case "learning_rate_reduction_multiplier":
+			cfg.LearningRateReductionMultiplier = float32(*multiplierPtr)
+		case "hidden_layers":
+			arch, err := nnet.ParseArchitecture(*hiddenLayersPtr)
+			if err != nil {
+				panic(err.Error())
+			}
+			cfg.Architecture = &arch
+		case "class_networks":
+			cfg.ClassNetworks = []string{}
+			if *classNetworksPtr != "" {
+				cfg.ClassNetworks = strings.Split(*classNetworksPtr, ",")
+			}
+		case "config_infile":
+			cfg.WeightsInFile = *inFilePathPtr
+		case "config_outfile":
+			cfg.WeightsOutFile = *outFilePathPtr
+		case "checkpoint_every_games":
+			cfg.CheckpointEveryGames = *checkpointGamesPtr
+		case "checkpoint_every_minutes":
+			cfg.CheckpointEveryMinutes = *checkpointMinutesPtr
+		case "checkpoints_to_keep":
+			cfg.CheckpointsToKeep = *checkpointsToKeepPtr
+		}
+	}
+	fs.Visit(applyFlags)
+	if cfg.WeightsOutFile == "" {
+		cfg.WeightsOutFile = filePathFromFlag(nil)
+	}
+
+	var latest *checkpoint.Checkpoint
+	if *resumePtr {
+		c, filePath, err := checkpoint.Latest(cfg.WeightsOutFile)
+		if err != nil {
+			panic("could not resume: " + err.Error())
+		}
+		fmt.Println("resuming from checkpoint", filePath)
+		latest, cfg = c, c.Config
+		// The checkpoint's network carries the learning params as they were when it was saved, so only flags override them.
+		cfg.LearningRate, cfg.EligibilityDecayRate = 0, 0
+		fs.Visit(applyFlags)
+	}
+	if cfg.WeightsInFile == "" {
+		cfg.WeightsInFile = filePathFromFlag(nil)
+	}
+	if err := cfg.Validate(); err != nil {
+		panic(err.Error())
+	}
+
+	trainer := newTrainer(cfg)
+	if latest != nil {
+		trainer.resumeFromCheckpoint(latest)
+	} else {
+		trainer.loadNeuralNetwork()
+	}
+	trainer.addClassNetworks()
+	useBearoffDatabases(trainer.nets, *bearoffDirPtr)
+	trainer.applyArchitecture()
+	trainer.applyLearningParams()
+	trainer.saveConfig()
+	ctx, cancel := signalContext()
+	defer cancel()
+	trainer.train(ctx)
+	trainer.saveNeuralNetwork(true /* waitForWrites=true*/)
+	trainer.writeVarianceLogs(true /* waitForWrites=true*/)
+}
+
+func mustLoadTrainConfig(filePath string) *trainconf.Config {
+	fmt.Println("loading training config from", filePath)
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		panic(fmt.Sprintf("could not open training config file %q: %v", filePath, err))
+	}
+	defer f.Close()
+
+	cfg, err := trainconf.Load(f)
+	if err != nil {
+		panic(fmt.Sprintf("could not load training config file %q: %v", filePath, err))
+	}
+	return cfg
+}
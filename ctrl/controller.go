@@ -2,13 +2,18 @@
 package ctrl
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/seriesoftubes/bgo/game"
 	"github.com/seriesoftubes/bgo/game/plyr"
 	"github.com/seriesoftubes/bgo/game/turn"
 	"github.com/seriesoftubes/bgo/game/turngen"
 	"github.com/seriesoftubes/bgo/learn"
+	"github.com/seriesoftubes/bgo/learn/met"
+	"github.com/seriesoftubes/bgo/learn/nnet"
 	"github.com/seriesoftubes/bgo/render"
 )
 
@@ -18,40 +23,64 @@ const (
 	msgNoMovesAvail = "\tcan't do anything this turn, sorry!"
 	msgForceMove    = "\tthis turn only has 1 option, forcing!"
 	msgAskForMove   = "\tYour move, "
+	msgAskToResign  = "\tNo choice this turn. Press enter to play on, or enter e.g. resign_1 to resign, "
 	msgChoseMove    = "\tChose move:"
+	msgResigned     = "\tresigned, for this many points:"
+	msgAskToAccept  = "\tAccept the resignation? (y/n), "
+	msgAccepted     = "\tresignation accepted!"
+	msgDeclined     = "\tresignation declined, play on!"
+	msgAskToDouble  = "\tDouble the cube (%v)? (y/n/hint), "
+	msgDoubled      = "\tdoubled, the cube is now:"
+	msgAskToTake    = "\tTake the double? (y/n/hint), "
+	msgTaken        = "\tdouble taken, play on!"
+	msgPassed       = "\tdouble passed!"
+	msgTimeout      = "\tran out of time!"
+	msgAbandoned    = "\tgame abandoned!"
+	msgMatchScore   = "\tMatch score:"
+
+	cmdprefixResign = "resign_"
+	cmdHint         = "hint"
+	cmdDouble       = "double"
+	cmdTake         = "take"
+	cmdPass         = "pass"
+	cmdprefixHint   = "hint_" // Followed by the ply, like "hint_2".
+	defaultHintPly  = 1
+	numHints        = 5
+	defaultEpsilon  = float32(1.0)
 )
 
-type GameController struct {
-	g         *game.Game
-	debug     bool
-	agent     *learn.Agent
-	prevBoard *game.Board
+// A MatchGame is one game of a match, along with the score that it was played at.
+type MatchGame struct {
+	Game  *game.Game
+	Match *learn.Match // The score before the game.
 }
 
-func New(debug bool) *GameController {
-	initialExplorationRateAkaEpsilon := float32(1.0)
-	agent := learn.NewAgent(initialExplorationRateAkaEpsilon)
-	return &GameController{agent: agent, debug: debug}
+type GameController struct {
+	g           *game.Game
+	debug       bool
+	players     map[plyr.Player]Player
+	prevBoard   *game.Board
+	timeControl *game.TimeControl // nil if games aren't timed.
+	learning    bool              // Whether the seated agents learn from the current game.
+	useCube     bool              // Whether games are played with the doubling cube.
+	abandoned   bool              // Whether a seat walked away from the current game without deciding, like a human whose stdin closed.
+	matchGames  []MatchGame       // The games of the most recent match, in order.
 }
 
-func readTurnFromStdin(p plyr.Player, validTurns map[turn.TurnArray]turn.Turn) turn.Turn {
-	fmt.Println(msgAskForMove, string(p))
-	for {
-		var supposedlySerializedTurn string
-		fmt.Scanln(&supposedlySerializedTurn)
+// New creates a controller where a single learning agent, which uses `nets`, plays against itself.
+func New(debug bool, nets *nnet.Set) *GameController {
+	return NewWithEpsilon(debug, nets, defaultEpsilon)
+}
 
-		t, err := turn.DeserializeTurn(supposedlySerializedTurn)
-		if err != nil {
-			fmt.Println("could not read your instructions, please try again: " + err.Error())
-			continue
-		}
+// NewWithEpsilon is like New, but the agent picks a random turn instead of the best one with a chance of `epsilon`.
+func NewWithEpsilon(debug bool, nets *nnet.Set, epsilon float32) *GameController {
+	agent := NewAgentPlayer(learn.NewAgent(nets, epsilon), 0)
+	return NewWithPlayers(debug, agent, agent)
+}
 
-		if _, ok := validTurns[t.Arrayify()]; ok {
-			return t
-		} else {
-			fmt.Println("invalid turn entered, please try again")
-		}
-	}
+// NewWithPlayers creates a controller where `pcc` plays as the counter-clockwise player, and `pc` plays as the clockwise one.
+func NewWithPlayers(debug bool, pcc, pc Player) *GameController {
+	return &GameController{debug: debug, players: map[plyr.Player]Player{plyr.PCC: pcc, plyr.PC: pc}}
 }
 
 func randomlyChooseValidTurn(validTurns map[turn.TurnArray]turn.Turn) turn.Turn {
@@ -61,21 +90,79 @@ func randomlyChooseValidTurn(validTurns map[turn.TurnArray]turn.Turn) turn.Turn
 	panic("no turns to choose. you should've prevented this line from being reached")
 }
 
-func (gc *GameController) WaitForStats()                    { gc.agent.WaitForStats() }
-func (gc *GameController) TransmitStatsFromMostRecentGame() { gc.agent.TransmitStats() }
+func (gc *GameController) WaitForStats() {
+	for _, a := range gc.agents() {
+		a.WaitForStats()
+	}
+}
+
+func (gc *GameController) TransmitStatsFromMostRecentGame() {
+	for _, a := range gc.agents() {
+		a.TransmitStats()
+	}
+}
+
+// SetPlayer seats `pl` as player `p` for every game from now on.
+func (gc *GameController) SetPlayer(p plyr.Player, pl Player) { gc.players[p] = pl }
+
+// SetTimeControl makes every game from now on timed with a chess clock.
+func (gc *GameController) SetTimeControl(tc game.TimeControl) { gc.timeControl = &tc }
+
+// UseCube makes every game from now on played with the doubling cube.
+func (gc *GameController) UseCube() { gc.useCube = true }
+
+// MostRecentGame returns the game that was played most recently, or nil if no games were played.
+func (gc *GameController) MostRecentGame() *game.Game { return gc.g }
+
+// MatchGames returns the games of the most recently played match, in order.
+func (gc *GameController) MatchGames() []MatchGame { return gc.matchGames }
+
+// ExportMostRecentGame writes the history of the most recently played game to `w`.
+func (gc *GameController) ExportMostRecentGame(w io.Writer) error { return gc.g.Export(w) }
+
+// PlayOneGame plays a game, and returns the winner and how much they won by.
+// If `ctx` is canceled before the game is over, the game is abandoned, and there's no winner.
+func (gc *GameController) PlayOneGame(ctx context.Context, stopLearning bool) (plyr.Player, game.WinKind) {
+	return gc.playOneGame(ctx, stopLearning, game.NewGame)
+}
+
+// PlayOneSeededGame plays a game whose starting player and dice rolls are all determined by `seed`.
+func (gc *GameController) PlayOneSeededGame(ctx context.Context, stopLearning bool, seed int64) (plyr.Player, game.WinKind) {
+	return gc.playOneGame(ctx, stopLearning, func(humans ...plyr.Player) *game.Game { return game.NewSeededGame(seed, humans...) })
+}
 
-func (gc *GameController) PlayOneGame(numHumanPlayers uint8, stopLearning bool) (plyr.Player, game.WinKind) {
-	gc.g = game.NewGame(numHumanPlayers)
+func (gc *GameController) playOneGame(ctx context.Context, stopLearning bool, newGame func(humans ...plyr.Player) *game.Game) (plyr.Player, game.WinKind) {
+	var humans []plyr.Player
+	for p, pl := range gc.players {
+		if _, isHuman := pl.(*HumanPlayer); isHuman {
+			humans = append(humans, p)
+		}
+	}
+	gc.g = newGame(humans...)
+	if gc.timeControl != nil {
+		gc.g.Clock = game.NewClock(*gc.timeControl)
+	}
+	if gc.useCube {
+		gc.g.Cube = game.NewCube()
+	}
 
-	if stopLearning {
-		gc.agent.StopLearning()
+	gc.learning, gc.abandoned = !stopLearning, false
+	for _, a := range gc.agents() {
+		if stopLearning {
+			a.StopLearning()
+		}
+		a.SetGame(gc.g)
 	}
-	gc.agent.SetGame(gc.g)
 
 	gc.maybePrint(msgWelcome)
 	var done bool
 	for !done {
-		done = gc.playOneTurn()
+		if ctx.Err() != nil || gc.abandoned {
+			gc.maybePrint(msgAbandoned)
+			gc.prevBoard = nil
+			return 0, game.WinKindNotWon
+		}
+		done = gc.playOneTurn(ctx)
 	}
 	gc.prevBoard = nil
 
@@ -87,6 +174,54 @@ func (gc *GameController) PlayOneGame(numHumanPlayers uint8, stopLearning bool)
 	return gc.g.Board.Winner(), gc.g.Board.WinKind()
 }
 
+// PlayMatch plays games with the doubling cube until a player has won a match to `length` points, and returns the winner. The
+// seated players judge their decisions by the match winning chances of `table`, and nobody may double in the Crawford game.
+// If `ctx` is canceled before the match is over, the match is abandoned, and there's no winner.
+func (gc *GameController) PlayMatch(ctx context.Context, length int, table *met.Table) plyr.Player {
+	useCube := gc.useCube
+	defer func() {
+		gc.useCube = useCube
+		gc.setMatch(nil)
+	}()
+
+	m := learn.NewMatch(table, length)
+	gc.matchGames = nil
+	for m.Winner() == 0 {
+		gc.setMatch(m)
+		gc.useCube = !m.Crawford
+		winner, _ := gc.PlayOneGame(ctx, true)
+		if winner == 0 {
+			return 0
+		}
+		gc.matchGames = append(gc.matchGames, MatchGame{Game: gc.g, Match: m})
+		m = m.After(winner, int(gc.g.Points()))
+		gc.maybePrint(msgMatchScore, m)
+	}
+	return m.Winner()
+}
+
+// setMatch tells the seated players which match they play in.
+func (gc *GameController) setMatch(m *learn.Match) {
+	for _, pl := range gc.players {
+		if mp, ok := pl.(matchPlayer); ok {
+			mp.SetMatch(m)
+		}
+	}
+}
+
+// agents returns the distinct agents that are seated in the game.
+func (gc *GameController) agents() []*learn.Agent {
+	var out []*learn.Agent
+	seen := map[*learn.Agent]bool{}
+	for _, p := range []plyr.Player{plyr.PCC, plyr.PC} {
+		if ap, isAgent := gc.players[p].(*AgentPlayer); isAgent && !seen[ap.agent] {
+			seen[ap.agent] = true
+			out = append(out, ap.agent)
+		}
+	}
+	return out
+}
+
 func (gc *GameController) maybePrint(s ...interface{}) {
 	if gc.g.HasAnyHumans() || gc.debug {
 		fmt.Println(s...)
@@ -94,47 +229,74 @@ func (gc *GameController) maybePrint(s ...interface{}) {
 }
 
 // playOneTurn plays through one turn, and returns whether the game is finished after the turn executes.
-func (gc *GameController) playOneTurn() bool {
+// If `ctx` is canceled while a player is deciding, or a player walks away, the turn isn't played and the game is abandoned.
+func (gc *GameController) playOneTurn(ctx context.Context) bool {
 	g := gc.g
 
+	gc.startClock()
+	if g.Cube != nil && g.Cube.MayDouble(g.CurrentPlayer) && gc.offerDouble(ctx) {
+		return g.Board.Winner() != 0
+	}
 	if g.HasAnyHumans() || gc.debug {
 		render.PrintGame(g)
 	}
 
 	validTurns := turngen.ValidTurns(g.Board, g.CurrentRoll, g.CurrentPlayer)
 
-	isComputer := !g.IsCurrentPlayerHuman()
+	seat := gc.players[g.CurrentPlayer]
+	learner, isLearner := seat.(*AgentPlayer)
+	isLearner = isLearner && gc.learning
 	var currentBoard *game.Board
-	if isComputer {
-		gc.agent.SetPlayer(g.CurrentPlayer)
+	if isLearner {
+		learner.agent.SetPlayer(g.CurrentPlayer)
 		currentBoard = g.Board.Copy()
 		if gc.prevBoard != nil {
-			gc.agent.LearnNonFinalState(gc.prevBoard, currentBoard)
+			learner.agent.LearnNonFinalState(gc.prevBoard, currentBoard)
 		}
 	}
 
 	var chosenTurn turn.Turn
-	if len(validTurns) == 0 {
-		gc.maybePrint(msgNoMovesAvail)
-	} else if len(validTurns) == 1 {
-		gc.maybePrint(msgForceMove)
-		chosenTurn = randomlyChooseValidTurn(validTurns)
+	if len(validTurns) <= 1 {
+		offer, ok := gc.askToResignForcedTurn(ctx, seat)
+		switch {
+		case !ok:
+			return gc.undecided(ctx)
+		case offer != game.WinKindNotWon && gc.offerResignation(ctx, validTurns, offer):
+			return g.Board.Winner() != 0
+		case len(validTurns) == 0:
+			gc.maybePrint(msgNoMovesAvail)
+		default:
+			gc.maybePrint(msgForceMove)
+			chosenTurn = randomlyChooseValidTurn(validTurns)
+		}
 	} else {
-		if !isComputer {
-			chosenTurn = readTurnFromStdin(g.CurrentPlayer, validTurns)
-		} else {
-			chosenTurn = gc.agent.EpsilonGreedyAction(currentBoard, validTurns)
+		for chosenTurn == nil {
+			timeout, stopTimeout := gc.turnTimeout(ctx)
+			t, offer, ok := seat.ChooseTurn(g, validTurns, timeout)
+			stopTimeout()
+			if !ok {
+				return gc.undecided(ctx)
+			} else if offer == game.WinKindNotWon {
+				chosenTurn = t
+			} else if gc.offerResignation(ctx, validTurns, offer) {
+				return g.Board.Winner() != 0
+			}
 		}
 	}
+	if flagged := gc.stopClock(); flagged {
+		gc.loseOnTime()
+		return true
+	}
 	gc.maybePrint(msgChoseMove, chosenTurn)
 
 	gc.prevBoard = currentBoard
 	g.Board.MustExecuteTurn(chosenTurn, gc.debug)
+	g.RecordTurn(chosenTurn)
 	winner, winAmt := g.Board.Winner(), g.Board.WinKind()
 
 	if winner != 0 {
-		if isComputer { // special case: a computer won, and they need to learn from that without having to re-run this playOneTurn method.
-			gc.agent.LearnFinal(currentBoard, g.Board, winAmt) // The `currentBoard` variable still reflects the state before the turn was executed.
+		if isLearner { // special case: an agent won, and they need to learn from that without having to re-run this playOneTurn method.
+			learner.agent.LearnFinal(currentBoard, g.Board, winAmt) // The `currentBoard` variable still reflects the state before the turn was executed.
 		}
 		return true
 	} else {
@@ -142,3 +304,149 @@ func (gc *GameController) playOneTurn() bool {
 		return false
 	}
 }
+
+// askToResignForcedTurn asks the current player, who has no choice to make this turn, whether to offer resigning before the turn
+// gets played for them. Seats that can't resign on such turns never do. It returns false if they didn't decide in time.
+func (gc *GameController) askToResignForcedTurn(ctx context.Context, seat Player) (game.WinKind, bool) {
+	fr, ok := seat.(forcedTurnResigner)
+	if !ok {
+		return game.WinKindNotWon, true
+	}
+	timeout, stopTimeout := gc.turnTimeout(ctx)
+	defer stopTimeout()
+	return fr.OffersResignation(gc.g, timeout)
+}
+
+// offerResignation lets the current player's enemy decide whether to accept a resignation. It returns whether the turn ends there,
+// because the game is over or because it was abandoned before the enemy decided. The resigner's clock is stopped while the enemy
+// decides, since that time isn't theirs, and it's only started again if they play on.
+func (gc *GameController) offerResignation(ctx context.Context, validTurns map[turn.TurnArray]turn.Turn, offer game.WinKind) bool {
+	g := gc.g
+	resigner := g.CurrentPlayer
+	gc.maybePrint(fmt.Sprintf("\t%s", resigner.Symbol()), msgResigned, offer)
+	if gc.stopClock() { // The resigner ran out of time before offering.
+		gc.loseOnTime()
+		return true
+	}
+
+	timeout, stopTimeout := gc.turnTimeout(ctx)
+	accepted, ok := gc.players[resigner.Enemy()].AcceptsResignation(g, validTurns, offer, timeout)
+	stopTimeout()
+	if !ok {
+		gc.abandoned = true
+		return true
+	}
+	g.RecordResignation(offer, accepted)
+	if !accepted {
+		gc.maybePrint(msgDeclined)
+		gc.startClock()
+		return false
+	}
+
+	gc.maybePrint(msgAccepted)
+	g.Board.Concede(resigner, offer)
+	return true
+}
+
+// offerDouble asks the current player whether to double before their roll, and then lets their enemy decide whether to take.
+// It returns whether the turn ends there, because the game is over or because it was abandoned. No clock is running once it has.
+// Like with resignations, the doubler's clock is stopped while the enemy decides, and only started again if the double is taken.
+func (gc *GameController) offerDouble(ctx context.Context) bool {
+	g := gc.g
+	doubler := g.CurrentPlayer
+	timeout, stopTimeout := gc.turnTimeout(ctx)
+	doubles, ok := gc.players[doubler].OffersDouble(g, timeout)
+	stopTimeout()
+	if !ok {
+		gc.undecided(ctx)
+		return true
+	} else if !doubles {
+		return false
+	}
+	gc.maybePrint(fmt.Sprintf("\t%s", doubler.Symbol()), msgDoubled, g.Cube.Value*2)
+	if gc.stopClock() { // The doubler ran out of time before doubling.
+		gc.loseOnTime()
+		return true
+	}
+
+	timeout, stopTimeout = gc.turnTimeout(ctx)
+	taken, ok := gc.players[doubler.Enemy()].AcceptsDouble(g, timeout)
+	stopTimeout()
+	if !ok {
+		gc.abandoned = true
+		return true
+	}
+	g.RecordDouble(taken)
+	if !taken {
+		gc.maybePrint(msgPassed)
+		g.Board.Concede(doubler.Enemy(), game.WinKindSingleGame) // Passing costs a single game at the cube's current value.
+		return true
+	}
+
+	gc.maybePrint(msgTaken)
+	g.Cube.Double(doubler.Enemy())
+	gc.startClock()
+	return false
+}
+
+// flagTimeout returns a channel that fires when the current player runs out of time, or nil if no clock is running.
+func (gc *GameController) flagTimeout() <-chan time.Time {
+	if gc.g.Clock == nil || gc.g.Clock.Running() == 0 {
+		return nil
+	}
+	return time.After(gc.g.Clock.TimeUntilFlag())
+}
+
+// turnTimeout is like flagTimeout, but the channel also fires when `ctx` is canceled. Call the returned func once the turn is chosen.
+func (gc *GameController) turnTimeout(ctx context.Context) (<-chan time.Time, func()) {
+	flag := gc.flagTimeout()
+	if ctx.Done() == nil {
+		return flag, func() {}
+	}
+
+	timeout, stop := make(chan time.Time, 1), make(chan bool)
+	go func() {
+		select {
+		case t := <-flag:
+			timeout <- t
+		case <-ctx.Done():
+			timeout <- time.Now()
+		case <-stop:
+		}
+	}()
+	return timeout, func() { close(stop) }
+}
+
+// undecided handles the current player, or their enemy, not deciding before the timeout fired. If the current player's clock ran
+// out, they lose on time. Otherwise `ctx` was canceled or the seat walked away, so the game is abandoned. It stops the current
+// player's clock, and returns whether the game is over.
+func (gc *GameController) undecided(ctx context.Context) bool {
+	if flagged := gc.stopClock(); flagged && ctx.Err() == nil {
+		gc.loseOnTime()
+		return true
+	}
+	gc.abandoned = true
+	return false
+}
+
+// loseOnTime ends the game with the current player losing on time, which costs a single game.
+func (gc *GameController) loseOnTime() {
+	gc.maybePrint(fmt.Sprintf("\t%s", gc.g.CurrentPlayer.Symbol()), msgTimeout)
+	gc.g.RecordTimeout()
+	gc.g.Board.Concede(gc.g.CurrentPlayer, game.WinKindSingleGame)
+}
+
+// startClock starts the current player's clock, if the game is timed.
+func (gc *GameController) startClock() {
+	if gc.g.Clock != nil {
+		gc.g.Clock.Start(gc.g.CurrentPlayer)
+	}
+}
+
+// stopClock stops the current player's clock, and returns whether they ran out of time.
+func (gc *GameController) stopClock() bool {
+	if gc.g.Clock == nil {
+		return false
+	}
+	return gc.g.Clock.Stop()
+}
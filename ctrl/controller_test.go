@@ -0,0 +1,260 @@
+package ctrl
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/game/turn"
+	"github.com/seriesoftubes/bgo/learn/met"
+)
+
+// waitingPlayer is like a human who never decides, so it only returns once its timeout fires.
+type waitingPlayer struct{ RandomPlayer }
+
+func (wp *waitingPlayer) ChooseTurn(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, timeout <-chan time.Time) (turn.Turn, game.WinKind, bool) {
+	<-timeout
+	return nil, game.WinKindNotWon, false
+}
+
+func TestPlayOneGameCanceled(t *testing.T) {
+	gc := NewWithPlayers(false, &RandomPlayer{}, &RandomPlayer{})
+	if winner, _ := gc.PlayOneGame(context.Background(), true); winner == 0 {
+		t.Errorf("expected a game that isn't canceled to have a winner")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if winner, winKind := gc.PlayOneGame(ctx, true); winner != 0 || winKind != game.WinKindNotWon {
+		t.Errorf("expected a canceled game to be abandoned, but %s won %v", winner.Symbol(), winKind)
+	}
+
+	// Canceling while a player is deciding should abandon the game, rather than count as the player running out of time.
+	gc = NewWithPlayers(false, &waitingPlayer{}, &waitingPlayer{})
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if winner, _ := gc.PlayOneGame(ctx, true); winner != 0 {
+		t.Errorf("expected a game that was canceled mid-turn to be abandoned, but %s won", winner.Symbol())
+	}
+	for _, he := range gc.MostRecentGame().History {
+		if he.TimedOut {
+			t.Errorf("expected no timeouts to be recorded, but got %+v", he)
+		}
+	}
+}
+
+// walkawayPlayer is like a human whose stdin was closed, so it gives up on every decision right away.
+type walkawayPlayer struct{ RandomPlayer }
+
+func (wp *walkawayPlayer) ChooseTurn(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, timeout <-chan time.Time) (turn.Turn, game.WinKind, bool) {
+	return nil, game.WinKindNotWon, false
+}
+
+func TestPlayOneGameWalkedAway(t *testing.T) {
+	gc := NewWithPlayers(false, &walkawayPlayer{}, &walkawayPlayer{})
+	if winner, winKind := gc.PlayOneGame(context.Background(), true); winner != 0 || winKind != game.WinKindNotWon {
+		t.Errorf("expected a game that a player walked away from to be abandoned, but %s won %v", winner.Symbol(), winKind)
+	}
+	for _, he := range gc.MostRecentGame().History {
+		if he.TimedOut {
+			t.Errorf("expected no timeouts to be recorded, but got %+v", he)
+		}
+	}
+}
+
+func TestScriptedPlayers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "script")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for script, wantErr := range map[string]bool{
+		"X;q3;s1\ndouble\nresign_2\n": false,
+		"":                            true,
+		"X;q3;s1\nresign_4\n":         true,
+		"X;q3;s1\nplay well\n":        true,
+	} {
+		filePath := filepath.Join(dir, "script.txt")
+		if err := ioutil.WriteFile(filePath, []byte(script), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := NewPlayerFromSpec(PlayerSpecScript+":"+filePath, nil); (err != nil) != wantErr {
+			t.Errorf("expected an error for script %q: %v, but got %v", script, wantErr, err)
+		}
+	}
+
+	// A script that runs out, or whose turn isn't valid for the roll, abandons the game instead of crashing.
+	for _, script := range [][]string{nil, {"X;c1;c2"}} { // X has no checkers on c.
+		gc := NewWithPlayers(false, NewScriptedPlayer(script, false), NewScriptedPlayer(script, false))
+		if winner, _ := gc.PlayOneGame(context.Background(), true); winner != 0 {
+			t.Errorf("expected the game with script %q to be abandoned, but %s won", script, winner.Symbol())
+		}
+	}
+}
+
+// forcedTurnResignerPlayer plays at random, and offers to resign on every turn where it has no choice to make.
+type forcedTurnResignerPlayer struct{ RandomPlayer }
+
+func (fp *forcedTurnResignerPlayer) OffersResignation(g *game.Game, timeout <-chan time.Time) (game.WinKind, bool) {
+	return game.WinKindSingleGame, true
+}
+
+func TestResignOnForcedTurn(t *testing.T) {
+	for _, accepted := range []bool{true, false} {
+		gc := NewWithPlayers(false, &forcedTurnResignerPlayer{}, NewScriptedPlayer(nil, accepted))
+		gc.g = game.NewGame()
+		b, err := game.ParsePosition("y:X1 a:O2 b:O2 c:O2 d:O2 e:O2 m:O5") // X can only enter from the bar with the 6 and play f1 with the 1.
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		gc.g.Board, gc.g.CurrentPlayer, gc.g.CurrentRoll = b, plyr.PCC, game.Roll{6, 1}
+
+		done := gc.playOneTurn(context.Background())
+		if h := gc.g.History; len(h) == 0 || h[0].ResignationOffer != game.WinKindSingleGame || h[0].ResignationAccepted != accepted {
+			t.Errorf("expected a resignation to be offered on the forced turn, but got %+v", h)
+		}
+		if accepted && (!done || gc.g.Board.Winner() != plyr.PC) {
+			t.Errorf("expected an accepted resignation to end the game, but got winner %q", gc.g.Board.Winner())
+		} else if !accepted && (done || gc.g.Board.BarCC != 0 || len(gc.g.History) != 2) {
+			t.Errorf("expected the forced turn to be played after a declined resignation, but got %+v", gc.g.History)
+		}
+	}
+}
+
+// resignOncePlayer offers to resign on its first turn, and plays at random after that.
+type resignOncePlayer struct {
+	RandomPlayer
+	resigned bool
+}
+
+func (rp *resignOncePlayer) ChooseTurn(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, timeout <-chan time.Time) (turn.Turn, game.WinKind, bool) {
+	if !rp.resigned {
+		rp.resigned = true
+		return nil, game.WinKindSingleGame, true
+	}
+	return rp.RandomPlayer.ChooseTurn(g, validTurns, timeout)
+}
+
+// slowDecliner takes `delay` to decline every resignation.
+type slowDecliner struct {
+	RandomPlayer
+	delay time.Duration
+}
+
+func (sd *slowDecliner) AcceptsResignation(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, offer game.WinKind, timeout <-chan time.Time) (bool, bool) {
+	time.Sleep(sd.delay)
+	return false, true
+}
+
+func TestResignationDecisionIsNotTimedForResigner(t *testing.T) {
+	const reserve = 50 * time.Millisecond
+	gc := NewWithPlayers(false, &resignOncePlayer{}, &slowDecliner{delay: 2 * reserve})
+	gc.g = game.NewGame()
+	gc.g.CurrentPlayer = plyr.PCC
+	gc.g.Clock = game.NewClock(game.TimeControl{Reserve: reserve})
+
+	if done := gc.playOneTurn(context.Background()); done {
+		t.Fatalf("expected the game to go on after a declined resignation, but got %+v", gc.g.History)
+	}
+	if h := gc.g.History; len(h) != 2 || h[0].ResignationOffer == game.WinKindNotWon || h[1].TimedOut || h[1].Turn == nil {
+		t.Errorf("expected a declined resignation and then a turn, but got %+v", h)
+	}
+	if remaining := gc.g.Clock.Remaining(plyr.PCC); remaining <= 0 {
+		t.Errorf("expected the resigner not to be charged for the enemy's decision, but they have %v left", remaining)
+	}
+}
+
+// cubePlayer plays at random, doubles whenever it may until it's doubled `numDoubles` times, and always takes or passes.
+type cubePlayer struct {
+	RandomPlayer
+	numDoubles int
+	takes      bool
+}
+
+func (cp *cubePlayer) OffersDouble(g *game.Game, timeout <-chan time.Time) (bool, bool) {
+	if cp.numDoubles == 0 {
+		return false, true
+	}
+	cp.numDoubles--
+	return true, true
+}
+
+func (cp *cubePlayer) AcceptsDouble(g *game.Game, timeout <-chan time.Time) (bool, bool) {
+	return cp.takes, true
+}
+
+// slowTaker takes `delay` to take every double.
+type slowTaker struct {
+	cubePlayer
+	delay time.Duration
+}
+
+func (st *slowTaker) AcceptsDouble(g *game.Game, timeout <-chan time.Time) (bool, bool) {
+	time.Sleep(st.delay)
+	return true, true
+}
+
+func TestTakeDecisionIsNotTimedForDoubler(t *testing.T) {
+	const reserve = 50 * time.Millisecond
+	gc := NewWithPlayers(false, &cubePlayer{numDoubles: 1}, &slowTaker{delay: 2 * reserve})
+	gc.g = game.NewGame()
+	gc.g.CurrentPlayer = plyr.PCC
+	gc.g.Cube = game.NewCube()
+	gc.g.Clock = game.NewClock(game.TimeControl{Reserve: reserve})
+
+	if done := gc.playOneTurn(context.Background()); done {
+		t.Fatalf("expected the game to go on after a taken double, but got %+v", gc.g.History)
+	}
+	if h := gc.g.History; len(h) != 2 || h[1].TimedOut || h[1].Turn == nil {
+		t.Errorf("expected a taken double and then a turn, but got %+v", h)
+	}
+	if remaining := gc.g.Clock.Remaining(plyr.PCC); remaining <= 0 {
+		t.Errorf("expected the doubler not to be charged for the enemy's decision, but they have %v left", remaining)
+	}
+}
+
+func TestPlayOneGameWithCube(t *testing.T) {
+	gc := NewWithPlayers(false, &cubePlayer{numDoubles: 1}, &cubePlayer{numDoubles: 1})
+	gc.UseCube()
+	winner, winKind := gc.PlayOneGame(context.Background(), true)
+	g := gc.MostRecentGame()
+	if starter := g.History[0].Player; winner != starter || winKind != game.WinKindSingleGame || g.Points() != 1 {
+		t.Errorf("expected a passed double to win a single point for %s, but %s won %d points", starter.Symbol(), winner.Symbol(), g.Points())
+	}
+	if len(g.History) != 1 || !g.History[0].Doubled || g.History[0].DoubleTaken {
+		t.Errorf("expected only a passed double to be recorded, but got %+v", g.History)
+	}
+
+	gc = NewWithPlayers(false, &cubePlayer{numDoubles: 1, takes: true}, &cubePlayer{numDoubles: 1, takes: true})
+	gc.UseCube()
+	winner, winKind = gc.PlayOneGame(context.Background(), true)
+	g = gc.MostRecentGame()
+	if g.Cube.Value != 4 || g.Cube.Owner != g.History[0].Player {
+		t.Errorf("expected a double and a redouble to leave the cube at 4 with the first doubler, but got %v", g.Cube)
+	}
+	if winner == 0 || g.Points() != uint16(winKind)*4 {
+		t.Errorf("expected the winner to win %d points, but got %d", uint16(winKind)*4, g.Points())
+	}
+}
+
+func TestPlayMatch(t *testing.T) {
+	gc := NewWithPlayers(false, &cubePlayer{numDoubles: 1, takes: true}, &RandomPlayer{})
+	if winner := gc.PlayMatch(context.Background(), 3, met.Default()); winner == 0 {
+		t.Errorf("expected the match to have a winner")
+	}
+	games := gc.MatchGames()
+	if len(games) == 0 || games[len(games)-1].Game != gc.MostRecentGame() {
+		t.Errorf("expected every game of the match to be kept, ending with the most recent one, but got %d games", len(games))
+	} else if m := games[0].Match; m.Away[plyr.PCC] != 3 || m.Away[plyr.PC] != 3 {
+		t.Errorf("expected the 1st game to be played at the starting score, but got %v", m)
+	}
+	if gc.useCube {
+		t.Errorf("expected the controller to go back to playing without the cube after the match")
+	}
+}
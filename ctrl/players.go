@@ -0,0 +1,538 @@
+package ctrl
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/turn"
+	"github.com/seriesoftubes/bgo/learn"
+	"github.com/seriesoftubes/bgo/learn/cube"
+	"github.com/seriesoftubes/bgo/learn/nnet"
+	"github.com/seriesoftubes/bgo/learn/pubeval"
+	"github.com/seriesoftubes/bgo/random"
+	"github.com/seriesoftubes/bgo/render"
+)
+
+const (
+	PlayerSpecHuman   = "human"
+	PlayerSpecRandom  = "random"
+	PlayerSpecPubeval = "pubeval"
+	PlayerSpecAgent   = "agent"  // Optionally followed by ":<ply>", like "agent:1", and then by ":<path to a weights file>".
+	PlayerSpecScript  = "script" // Must be followed by ":<path to a file with one serialized turn per line>".
+
+	playerSpecDelim = ":"
+)
+
+var (
+	stdinOnce  sync.Once
+	stdinLines chan string
+)
+
+type (
+	// A Player sits in one of the seats of a game, and makes that seat's decisions.
+	Player interface {
+		// ChooseTurn picks one of `validTurns`, which has at least 2 turns to choose from. Instead of a turn, it may return a
+		// non-zero WinKind to offer resigning for that many points. It returns false if `timeout` fired before it decided.
+		ChooseTurn(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, timeout <-chan time.Time) (turn.Turn, game.WinKind, bool)
+		// AcceptsResignation decides whether to accept the current player's offer to resign for `offer` points, instead of
+		// letting them play one of `validTurns`. It returns false as its 2nd value if `timeout` fired before it decided.
+		AcceptsResignation(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, offer game.WinKind, timeout <-chan time.Time) (bool, bool)
+		// OffersDouble decides whether the current player doubles before their roll. It's only asked when they may double.
+		// It returns false as its 2nd value if `timeout` fired before it decided.
+		OffersDouble(g *game.Game, timeout <-chan time.Time) (bool, bool)
+		// AcceptsDouble decides whether to take the current player's double, instead of passing and losing the game.
+		// It returns false as its 2nd value if `timeout` fired before it decided.
+		AcceptsDouble(g *game.Game, timeout <-chan time.Time) (bool, bool)
+	}
+
+	// A forcedTurnResigner is a Player that may offer to resign at the start of a turn where it has no choice to make, which
+	// ChooseTurn never gets asked about.
+	forcedTurnResigner interface {
+		// OffersResignation returns a non-zero WinKind to offer resigning for that many points, before the turn gets played for it.
+		// It returns false if `timeout` fired before it decided.
+		OffersResignation(g *game.Game, timeout <-chan time.Time) (game.WinKind, bool)
+	}
+
+	// A matchPlayer is a Player whose decisions depend on the score of the match that it plays in.
+	matchPlayer interface {
+		SetMatch(m *learn.Match)
+	}
+
+	// A HumanPlayer makes decisions by typing them into stdin.
+	HumanPlayer struct {
+		nets     *nnet.Set    // The networks that hints come from.
+		tutor    *Tutor       // nil if the human isn't being tutored.
+		cubeLife float32      // How live cube hints assume the cube to be.
+		match    *learn.Match // The match that hints and the tutor judge decisions in, or nil for money.
+	}
+
+	// A RandomPlayer picks any valid turn at random, never accepts resignations, never doubles and always takes.
+	RandomPlayer struct{}
+
+	// A PubevalPlayer plays whichever turn Tesauro's pubeval likes best, never accepts resignations, never doubles and always takes.
+	PubevalPlayer struct{}
+
+	// An AgentPlayer lets a neural network agent decide, by looking `ply` rolls ahead.
+	AgentPlayer struct {
+		agent *learn.Agent
+		ply   int
+	}
+
+	// A ScriptedPlayer plays a fixed list of serialized turns, in order. Script entries like "resign_2" offer a resignation instead.
+	// A "double" entry doubles before the next turn, and a "take" or "pass" entry answers the enemy's double. Unscripted doubles
+	// are taken. If the script runs out, or its next turn isn't valid, the player walks away and the game is abandoned.
+	ScriptedPlayer struct {
+		script              []string
+		nextIdx             int
+		acceptsResignations bool
+	}
+)
+
+func NewAgentPlayer(agent *learn.Agent, ply int) *AgentPlayer {
+	if ply < 0 || ply > learn.MaxPly {
+		panic(fmt.Sprintf("ply must be between 0 and %d, but got %d", learn.MaxPly, ply))
+	}
+	return &AgentPlayer{agent: agent, ply: ply}
+}
+
+// NewHumanPlayer creates a human player whose hints come from `nets`.
+func NewHumanPlayer(nets *nnet.Set) *HumanPlayer {
+	return &HumanPlayer{nets: nets, cubeLife: cube.DefaultCubeLife}
+}
+
+func NewScriptedPlayer(script []string, acceptsResignations bool) *ScriptedPlayer {
+	return &ScriptedPlayer{script: script, acceptsResignations: acceptsResignations}
+}
+
+// NewPlayerFromSpec creates a Player from a spec like "human", "random", "pubeval", "agent:1", "agent:1:/path/to/weights.json" or
+// "script:/path/to/turns.txt". Agents and hints use `nets`, unless the spec names a weights file. Agents created this way never explore.
+func NewPlayerFromSpec(spec string, nets *nnet.Set) (Player, error) {
+	kind, arg := spec, ""
+	if idx := strings.Index(spec, playerSpecDelim); idx >= 0 {
+		kind, arg = spec[:idx], spec[idx+1:]
+	}
+
+	switch kind {
+	case PlayerSpecHuman:
+		return NewHumanPlayer(nets), nil
+	case PlayerSpecRandom:
+		return &RandomPlayer{}, nil
+	case PlayerSpecPubeval:
+		return &PubevalPlayer{}, nil
+	case PlayerSpecAgent:
+		plyArg, weightsFilePath := arg, ""
+		if idx := strings.Index(arg, playerSpecDelim); idx >= 0 {
+			plyArg, weightsFilePath = arg[:idx], arg[idx+1:]
+		}
+
+		ply := 0
+		if plyArg != "" {
+			var err error
+			if ply, err = strconv.Atoi(plyArg); err != nil || ply < 0 || ply > learn.MaxPly {
+				return nil, fmt.Errorf("invalid ply %q in player spec %q, must be between 0 and %d", plyArg, spec, learn.MaxPly)
+			}
+		}
+		if weightsFilePath != "" {
+			exact := nets.ExactEvaluator()
+			var err error
+			if nets, _, err = nnet.LoadSetFiles(weightsFilePath); err != nil {
+				return nil, fmt.Errorf("could not load the weights for player spec %q: %v", spec, err)
+			}
+			nets.SetExactEvaluator(exact)
+		}
+		return NewAgentPlayer(learn.NewAgent(nets, 0), ply), nil
+	case PlayerSpecScript:
+		f, err := os.Open(arg)
+		if err != nil {
+			return nil, fmt.Errorf("could not open script for player spec %q: %v", spec, err)
+		}
+		defer f.Close()
+
+		var script []string
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			if line := strings.TrimSpace(sc.Text()); line != "" {
+				script = append(script, line)
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return nil, fmt.Errorf("could not read script for player spec %q: %v", spec, err)
+		}
+		if err := checkScript(script); err != nil {
+			return nil, fmt.Errorf("invalid script for player spec %q: %v", spec, err)
+		}
+		return NewScriptedPlayer(script, false), nil
+	}
+
+	return nil, fmt.Errorf("unknown player spec %q, should be one of %q, %q, %q, %q or %q", spec, PlayerSpecHuman, PlayerSpecRandom, PlayerSpecPubeval, PlayerSpecAgent+":<ply>[:<weights>]", PlayerSpecScript+":<path>")
+}
+
+// SetTutor makes `tu` check the human's turns from now on. A nil tutor stops the checks.
+func (hp *HumanPlayer) SetTutor(tu *Tutor) { hp.tutor = tu }
+
+// SetCubeLife sets how live cube hints assume the cube to be, between 0 and 1.
+func (hp *HumanPlayer) SetCubeLife(cubeLife float32) { hp.cubeLife = cubeLife }
+
+// SetMatch makes hints and the tutor judge decisions by their match winning chances in `m`. A nil match judges them for money.
+func (hp *HumanPlayer) SetMatch(m *learn.Match) { hp.match = m }
+
+func (hp *HumanPlayer) ChooseTurn(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, timeout <-chan time.Time) (turn.Turn, game.WinKind, bool) {
+	for {
+		t, offer, ok := hp.readTurnFromStdin(g, validTurns, timeout)
+		if !ok || offer != game.WinKindNotWon || hp.tutor == nil {
+			return t, offer, ok
+		}
+
+		approved, ok := hp.tutor.approves(g, validTurns, t, hp.match, timeout)
+		if !ok {
+			return nil, game.WinKindNotWon, false
+		} else if approved {
+			return t, offer, true
+		}
+	}
+}
+
+// OffersResignation lets the human resign on a turn where they have no choice to make, or press enter to play on.
+func (hp *HumanPlayer) OffersResignation(g *game.Game, timeout <-chan time.Time) (game.WinKind, bool) {
+	fmt.Println(msgAskToResign, string(g.CurrentPlayer))
+	for {
+		answer, ok := readStdinLine(timeout)
+		if !ok {
+			return game.WinKindNotWon, false
+		} else if answer == "" {
+			return game.WinKindNotWon, true
+		}
+
+		offer, err := resignationFromCommand(answer)
+		if err != nil {
+			fmt.Println(err.Error())
+			continue
+		}
+		return offer, true
+	}
+}
+
+func (hp *HumanPlayer) AcceptsResignation(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, offer game.WinKind, timeout <-chan time.Time) (bool, bool) {
+	return readYesOrNoFromStdin(timeout, msgAskToAccept, string(g.CurrentPlayer.Enemy()))
+}
+
+func (hp *HumanPlayer) OffersDouble(g *game.Game, timeout <-chan time.Time) (bool, bool) {
+	render.PrintBoard(g.Board)
+	return hp.readCubeAnswerFromStdin(g, timeout, fmt.Sprintf(msgAskToDouble, g.Cube), string(g.CurrentPlayer))
+}
+
+func (hp *HumanPlayer) AcceptsDouble(g *game.Game, timeout <-chan time.Time) (bool, bool) {
+	return hp.readCubeAnswerFromStdin(g, timeout, msgAskToTake, string(g.CurrentPlayer.Enemy()))
+}
+
+func (rp *RandomPlayer) ChooseTurn(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, timeout <-chan time.Time) (turn.Turn, game.WinKind, bool) {
+	chosenIdx, idx := random.IntUpTo(len(validTurns)), 0
+	for _, t := range validTurns {
+		if idx == chosenIdx {
+			return t, game.WinKindNotWon, true
+		}
+		idx++
+	}
+	panic("no turns to choose. you should've prevented this line from being reached")
+}
+
+func (rp *RandomPlayer) AcceptsResignation(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, offer game.WinKind, timeout <-chan time.Time) (bool, bool) {
+	return false, true
+}
+
+func (rp *RandomPlayer) OffersDouble(g *game.Game, timeout <-chan time.Time) (bool, bool) {
+	return false, true
+}
+
+func (rp *RandomPlayer) AcceptsDouble(g *game.Game, timeout <-chan time.Time) (bool, bool) {
+	return true, true
+}
+
+func (pp *PubevalPlayer) ChooseTurn(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, timeout <-chan time.Time) (turn.Turn, game.WinKind, bool) {
+	return pubeval.BestTurn(g.Board, validTurns, g.CurrentPlayer), game.WinKindNotWon, true
+}
+
+func (pp *PubevalPlayer) AcceptsResignation(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, offer game.WinKind, timeout <-chan time.Time) (bool, bool) {
+	return false, true
+}
+
+func (pp *PubevalPlayer) OffersDouble(g *game.Game, timeout <-chan time.Time) (bool, bool) {
+	return false, true
+}
+
+func (pp *PubevalPlayer) AcceptsDouble(g *game.Game, timeout <-chan time.Time) (bool, bool) {
+	return true, true
+}
+
+// SetCubeLife sets how live the agent assumes the cube to be, between 0 and 1.
+func (ap *AgentPlayer) SetCubeLife(cubeLife float32) { ap.agent.SetCubeLife(cubeLife) }
+
+// SetMatch makes the agent judge its decisions by their match winning chances in `m`. A nil match makes it play for money.
+func (ap *AgentPlayer) SetMatch(m *learn.Match) { ap.agent.SetMatch(m) }
+
+func (ap *AgentPlayer) ChooseTurn(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, timeout <-chan time.Time) (turn.Turn, game.WinKind, bool) {
+	ap.agent.SetPlayer(g.CurrentPlayer)
+
+	if g.Clock != nil {
+		return ap.agent.EpsilonGreedyActionWithin(g.Board, validTurns, ap.ply, time.Now().Add(ap.thinkingBudget(g))), game.WinKindNotWon, true
+	} else if ap.ply == 0 {
+		return ap.agent.EpsilonGreedyAction(g.Board, validTurns), game.WinKindNotWon, true
+	}
+	return ap.agent.BestTurn(g.Board, validTurns, ap.ply), game.WinKindNotWon, true
+}
+
+func (ap *AgentPlayer) AcceptsResignation(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, offer game.WinKind, timeout <-chan time.Time) (bool, bool) {
+	return ap.agent.AcceptsResignation(g.Board, validTurns, g.CurrentPlayer, offer), true
+}
+
+func (ap *AgentPlayer) OffersDouble(g *game.Game, timeout <-chan time.Time) (bool, bool) {
+	return ap.agent.OffersDouble(g.Board, g.CurrentPlayer, g.Cube, ap.ply), true
+}
+
+func (ap *AgentPlayer) AcceptsDouble(g *game.Game, timeout <-chan time.Time) (bool, bool) {
+	return ap.agent.AcceptsDouble(g.Board, g.CurrentPlayer, g.Cube, ap.ply), true
+}
+
+// thinkingBudget returns how long the agent may think about the current turn, never risking more than half its remaining time.
+func (ap *AgentPlayer) thinkingBudget(g *game.Game) time.Duration {
+	budget := learn.ThinkingBudget(g.Board, g.CurrentPlayer, g.Clock.Remaining(g.CurrentPlayer)) + g.Clock.Delay()
+	if maxBudget := g.Clock.TimeUntilFlag() / 2; budget > maxBudget {
+		return maxBudget
+	}
+	return budget
+}
+
+func (sp *ScriptedPlayer) ChooseTurn(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, timeout <-chan time.Time) (turn.Turn, game.WinKind, bool) {
+	if sp.nextIdx >= len(sp.script) {
+		fmt.Printf("the script for player %s ran out after %d entries\n", g.CurrentPlayer.Symbol(), len(sp.script))
+		return nil, game.WinKindNotWon, false
+	}
+	entry := sp.script[sp.nextIdx]
+	sp.nextIdx++
+
+	if strings.HasPrefix(entry, cmdprefixResign) {
+		offer, err := resignationFromCommand(entry)
+		if err != nil {
+			fmt.Printf("invalid script entry #%d: %v\n", sp.nextIdx, err)
+			return nil, game.WinKindNotWon, false
+		}
+		return nil, offer, true
+	}
+
+	t, err := turn.DeserializeTurn(entry)
+	if err != nil {
+		fmt.Printf("invalid script entry #%d: %v\n", sp.nextIdx, err)
+		return nil, game.WinKindNotWon, false
+	}
+	if _, ok := validTurns[t.Arrayify()]; !ok {
+		fmt.Printf("script entry #%d (%v) isn't a valid turn for roll %v\n", sp.nextIdx, t, g.CurrentRoll)
+		return nil, game.WinKindNotWon, false
+	}
+	return t, game.WinKindNotWon, true
+}
+
+func (sp *ScriptedPlayer) AcceptsResignation(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, offer game.WinKind, timeout <-chan time.Time) (bool, bool) {
+	return sp.acceptsResignations, true
+}
+
+func (sp *ScriptedPlayer) OffersDouble(g *game.Game, timeout <-chan time.Time) (bool, bool) {
+	return sp.skipEntry(cmdDouble), true
+}
+
+func (sp *ScriptedPlayer) AcceptsDouble(g *game.Game, timeout <-chan time.Time) (bool, bool) {
+	sp.skipEntry(cmdTake)
+	return !sp.skipEntry(cmdPass), true
+}
+
+// skipEntry moves past the next script entry if it's `entry`, and returns whether it did.
+// checkScript returns an error for the first entry of `script` that's neither a serialized turn nor a resignation or cube command.
+// Whether a turn is valid for its roll can only be told once the game gets there.
+func checkScript(script []string) error {
+	if len(script) == 0 {
+		return fmt.Errorf("the script has no entries")
+	}
+	for i, entry := range script {
+		var err error
+		switch {
+		case entry == cmdDouble || entry == cmdTake || entry == cmdPass:
+		case strings.HasPrefix(entry, cmdprefixResign):
+			_, err = resignationFromCommand(entry)
+		default:
+			_, err = turn.DeserializeTurn(entry)
+		}
+		if err != nil {
+			return fmt.Errorf("entry #%d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (sp *ScriptedPlayer) skipEntry(entry string) bool {
+	if sp.nextIdx < len(sp.script) && sp.script[sp.nextIdx] == entry {
+		sp.nextIdx++
+		return true
+	}
+	return false
+}
+
+// readStdinLine waits for the next line typed into stdin, and returns false if `timeout` fires first, or if stdin was closed.
+func readStdinLine(timeout <-chan time.Time) (string, bool) {
+	stdinOnce.Do(func() {
+		stdinLines = make(chan string)
+		go func() {
+			sc := bufio.NewScanner(os.Stdin)
+			for sc.Scan() {
+				stdinLines <- sc.Text()
+			}
+			close(stdinLines)
+		}()
+	})
+
+	select {
+	case line, ok := <-stdinLines:
+		if !ok { // Stdin was closed, so no more lines will come.
+			return "", false
+		}
+		return strings.TrimSpace(line), true
+	case <-timeout:
+		return "", false
+	}
+}
+
+// readTurnFromStdin asks the human for a turn. Instead of a turn, the human may enter e.g. "resign_2" to offer resigning for 2 points,
+// in which case the returned WinKind is non-zero, or "hint" to see the best turns. It returns false if `timeout` fires before the
+// human is done.
+func (hp *HumanPlayer) readTurnFromStdin(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, timeout <-chan time.Time) (turn.Turn, game.WinKind, bool) {
+	fmt.Println(msgAskForMove, string(g.CurrentPlayer))
+	for {
+		supposedlySerializedTurn, ok := readStdinLine(timeout)
+		if !ok {
+			return nil, game.WinKindNotWon, false
+		}
+
+		if supposedlySerializedTurn == cmdHint || strings.HasPrefix(supposedlySerializedTurn, cmdprefixHint) {
+			if ply, err := hintPlyFromCommand(supposedlySerializedTurn); err != nil {
+				fmt.Println(err.Error())
+			} else {
+				printHints(hp.nets, g, validTurns, ply, hp.match)
+			}
+			continue
+		}
+
+		if strings.HasPrefix(supposedlySerializedTurn, cmdprefixResign) {
+			offer, err := resignationFromCommand(supposedlySerializedTurn)
+			if err != nil {
+				fmt.Println(err.Error())
+				continue
+			}
+			return nil, offer, true
+		}
+
+		t, err := turn.DeserializeTurn(supposedlySerializedTurn)
+		if err != nil {
+			fmt.Println("could not read your instructions, please try again: " + err.Error())
+			continue
+		}
+
+		if _, ok := validTurns[t.Arrayify()]; ok {
+			return t, game.WinKindNotWon, true
+		} else {
+			fmt.Println("invalid turn entered, please try again")
+		}
+	}
+}
+
+// readCubeAnswerFromStdin asks the human a yes or no question about the cube. Instead of answering, the human may enter "hint"
+// or e.g. "hint_2" to see what the current player and their enemy should do with the cube. It returns false as its 2nd value if
+// `timeout` fires before they answer.
+func (hp *HumanPlayer) readCubeAnswerFromStdin(g *game.Game, timeout <-chan time.Time, question ...interface{}) (bool, bool) {
+	fmt.Println(question...)
+	for {
+		answer, ok := readStdinLine(timeout)
+		if !ok {
+			return false, false
+		}
+		if answer == cmdHint || strings.HasPrefix(answer, cmdprefixHint) {
+			if ply, err := hintPlyFromCommand(answer); err != nil {
+				fmt.Println(err.Error())
+			} else {
+				fmt.Printf("\t%v (%d-ply)\n", learn.CubeDecision(hp.nets, g.Board, g.CurrentPlayer, g.Cube, hp.cubeLife, ply, hp.match), ply)
+			}
+			continue
+		}
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return true, true
+		case "n", "no":
+			return false, true
+		}
+		fmt.Println("please answer 'y', 'n' or 'hint'")
+	}
+}
+
+func resignationFromCommand(cmd string) (game.WinKind, error) {
+	amt, err := strconv.Atoi(strings.TrimPrefix(cmd, cmdprefixResign))
+	if err != nil || game.WinKind(amt) < game.WinKindSingleGame || game.WinKind(amt) > game.WinKindBackgammon {
+		return game.WinKindNotWon, fmt.Errorf("invalid resignation %q, should look like 'resign_1', 'resign_2' or 'resign_3'", cmd)
+	}
+	return game.WinKind(amt), nil
+}
+
+func hintPlyFromCommand(cmd string) (int, error) {
+	if cmd == cmdHint {
+		return defaultHintPly, nil
+	}
+	ply, err := strconv.Atoi(strings.TrimPrefix(cmd, cmdprefixHint))
+	if err != nil || ply < 0 || ply > learn.MaxPly {
+		return 0, fmt.Errorf("invalid hint %q, should look like 'hint' or 'hint_N' where N is between 0 and %d", cmd, learn.MaxPly)
+	}
+	return ply, nil
+}
+
+// printHints shows the current player's best few turns, ranked by `nets`' evaluation `ply` rolls ahead. In match `m`, they're
+// ranked by their match winning chances instead of their equities.
+func printHints(nets *nnet.Set, g *game.Game, validTurns map[turn.TurnArray]turn.Turn, ply int, m *learn.Match) {
+	ranked := learn.RankTurnsInMatch(nets, g.Board, validTurns, g.CurrentPlayer, ply, numHints, m, learn.CubeValue(g.Cube))
+	if len(ranked) > numHints {
+		ranked = ranked[:numHints]
+	}
+
+	scale, format, diffFormat := float32(1), "%+.3f", "%+.3f"
+	if m != nil {
+		scale, format, diffFormat = 100, "%.1f%%", "%+.1f%%"
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for i, rt := range ranked {
+		diff := ""
+		if i > 0 {
+			diff = fmt.Sprintf(diffFormat, scale*(rt.Equity-ranked[0].Equity))
+		}
+		fmt.Fprintf(tw, "\t%d.\t%s\t%v\t%s\t%s\t(%d-ply)\n", i+1, g.Board.Notation(rt.Turn), rt.Turn, fmt.Sprintf(format, scale*rt.Equity), diff, rt.Ply)
+	}
+	tw.Flush()
+}
+
+// readYesOrNoFromStdin asks the human a yes or no question, and returns false as its 2nd value if `timeout` fires before they answer.
+func readYesOrNoFromStdin(timeout <-chan time.Time, question ...interface{}) (bool, bool) {
+	fmt.Println(question...)
+	for {
+		answer, ok := readStdinLine(timeout)
+		if !ok {
+			return false, false
+		}
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return true, true
+		case "n", "no":
+			return false, true
+		}
+		fmt.Println("please answer 'y' or 'n'")
+	}
+}
@@ -0,0 +1,58 @@
+package ctrl
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/turn"
+	"github.com/seriesoftubes/bgo/learn"
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+const (
+	msgTutorWarning = "\tTutor: that turn is %s, it loses %s. The best turn is %s (%v)."
+	msgTutorConfirm = "\tPlay it anyway? (y/n)"
+)
+
+// A Tutor warns a human before they play a turn that loses too much equity compared to the best turn.
+type Tutor struct {
+	Thresholds learn.Thresholds
+	MinQuality learn.MoveQuality // Only turns that are at least this bad get a warning.
+	Ply        int               // How many rolls ahead to look when judging turns.
+	nets       *nnet.Set
+}
+
+// NewTutor creates a tutor that judges turns with `nets`.
+func NewTutor(nets *nnet.Set, minQuality learn.MoveQuality, ply int) *Tutor {
+	if ply < 0 || ply > learn.MaxPly {
+		panic(fmt.Sprintf("ply must be between 0 and %d, but got %d", learn.MaxPly, ply))
+	}
+	return &Tutor{Thresholds: learn.DefaultThresholds, MinQuality: minQuality, Ply: ply, nets: nets}
+}
+
+// approves decides whether the human should go through with playing `t`, asking them to confirm it if it's too costly.
+// In match `m`, turns are judged by their match winning chances, and the MWC that `t` loses is classified like the equity it's
+// worth. It returns false as its 2nd value if `timeout` fires before the human answers.
+func (tu *Tutor) approves(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, t turn.Turn, m *learn.Match, timeout <-chan time.Time) (bool, bool) {
+	cubeValue := learn.CubeValue(g.Cube)
+	loss, best := learn.NewSearch(tu.nets, tu.Ply).InMatch(m, cubeValue).EquityLoss(g.Board, validTurns, t, g.CurrentPlayer)
+	equityLoss, lossText := loss, fmt.Sprintf("%.3f equity", loss)
+	if m != nil {
+		equityLoss, lossText = m.EquivalentEquity(g.CurrentPlayer, loss, cubeValue), fmt.Sprintf("%.1f%% match winning chances", 100*loss)
+	}
+	quality := tu.Thresholds.Classify(equityLoss)
+	if quality == learn.QualityGood || quality < tu.MinQuality {
+		return true, true
+	}
+
+	fmt.Printf(msgTutorWarning+"\n", articled(quality), lossText, g.Board.Notation(best.Turn), best.Turn)
+	return readYesOrNoFromStdin(timeout, msgTutorConfirm)
+}
+
+func articled(q learn.MoveQuality) string {
+	if q == learn.QualityError {
+		return "an " + q.String()
+	}
+	return "a " + q.String()
+}
@@ -173,36 +173,55 @@ func (b *Board) LegalMoves(p plyr.Player, diceAmt uint8) []turn.Move {
 // MustExecuteTurn takes a Turn, and executes its individual moves, in an order that won't explode the game.
 // This is mainly to support the stdin UX of supplying entire, serialized Turns (the UX should be improved to do 1 Move at a time instead of a whole Turn though).
 func (b *Board) MustExecuteTurn(t turn.Turn, debug bool) {
-	var mustExec func(m turn.Move, times uint8)
-	if debug {
-		mustExec = func(m turn.Move, times uint8) {
-			for i := uint8(0); i < times; i++ {
-				if ok, reason := b.ExecuteMoveIfLegal(m); !ok {
-					panic(fmt.Sprintf("we couldn't execute Move %v for the %d'th time, as part of supposedly-valid Turn %v, because %s", m, i, t, reason))
-				}
+	for _, mtp := range executionOrder(t) {
+		for i := uint8(0); i < mtp.times; i++ {
+			if !debug {
+				b.ExecuteMoveUnsafe(mtp.mo)
+			} else if ok, reason := b.ExecuteMoveIfLegal(mtp.mo); !ok {
+				panic(fmt.Sprintf("we couldn't execute Move %v for the %d'th time, as part of supposedly-valid Turn %v, because %s", mtp.mo, i, t, reason))
 			}
 		}
-	} else {
-		mustExec = func(m turn.Move, times uint8) {
-			for i := uint8(0); i < times; i++ {
-				b.ExecuteMoveUnsafe(m)
+	}
+}
+
+// ExecuteTurnIfLegal executes a Turn only if every one of its moves is legal, and otherwise leaves the board untouched.
+// Unlike turngen.ValidTurns, it doesn't check whether the turn uses as much of the roll as possible.
+func (b *Board) ExecuteTurnIfLegal(t turn.Turn) (bool, string) {
+	cop := b.Copy()
+	for _, mtp := range executionOrder(t) {
+		for i := uint8(0); i < mtp.times; i++ {
+			if ok, reason := cop.ExecuteMoveIfLegal(mtp.mo); !ok {
+				return false, reason
 			}
 		}
 	}
 
-	var sortable sortableMotimesPairs
+	*b = *cop
+	return true, ""
+}
+
+// Concede ends the game in favor of `loser`'s enemy, who wins `wk` points. E.g., when `loser` resigns.
+// It's a no-op if the game was already won.
+func (b *Board) Concede(loser plyr.Player, wk WinKind) {
+	if b.winner != 0 {
+		return
+	}
+	b.winner, b.winKind = loser.Enemy(), wk
+}
+
+// executionOrder orders a Turn's moves so that bar moves go first, and no checker is moved before the checkers it could depend on.
+func executionOrder(t turn.Turn) []motimesPair {
+	var barMoves, sortable sortableMotimesPairs
 	for move, numTimes := range t {
 		if p := move.Requestor; (p == plyr.PCC && move.Letter == constants.LETTER_BAR_CC) || (p == plyr.PC && move.Letter == constants.LETTER_BAR_C) {
-			mustExec(move, numTimes)
+			barMoves = append(barMoves, motimesPair{move, numTimes})
 			continue
 		}
 		sortable = append(sortable, motimesPair{move, numTimes})
 	}
 	sort.Sort(sortable)
 
-	for _, mtp := range sortable {
-		mustExec(mtp.mo, mtp.times)
-	}
+	return append(barMoves, sortable...)
 }
 
 func (b *Board) ExecuteMoveUnsafe(m turn.Move) {
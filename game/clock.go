@@ -0,0 +1,122 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/seriesoftubes/bgo/game/plyr"
+)
+
+const (
+	DelayKindNone DelayKind = iota
+	// With a simple delay, a player's reserve doesn't start running down until the delay has passed on each move.
+	DelayKindSimple
+	// With a Bronstein delay, the reserve runs down right away, but the time used up to the delay is added back after each move.
+	DelayKindBronstein
+)
+
+type (
+	DelayKind uint8
+
+	// A TimeControl describes how much time each player gets.
+	TimeControl struct {
+		Reserve   time.Duration // The time each player has for the whole game.
+		Delay     time.Duration // The per-move delay.
+		DelayKind DelayKind
+	}
+
+	// A Clock is a chess clock: only one player's time runs at once, and a player whose reserve runs out loses the game.
+	Clock struct {
+		tc        TimeControl
+		reserves  map[plyr.Player]time.Duration
+		running   plyr.Player // 0 if nobody's clock is running.
+		startedAt time.Time
+		now       func() time.Time
+	}
+)
+
+func ParseDelayKind(s string) (DelayKind, error) {
+	switch s {
+	case "", "none":
+		return DelayKindNone, nil
+	case "simple":
+		return DelayKindSimple, nil
+	case "bronstein":
+		return DelayKindBronstein, nil
+	}
+	return DelayKindNone, fmt.Errorf("unknown delay kind %q, should be one of 'none', 'simple' or 'bronstein'", s)
+}
+
+func NewClock(tc TimeControl) *Clock {
+	return &Clock{
+		tc:       tc,
+		reserves: map[plyr.Player]time.Duration{plyr.PCC: tc.Reserve, plyr.PC: tc.Reserve},
+		now:      time.Now,
+	}
+}
+
+// Start starts running `p`'s clock. It must not be called while any clock is running.
+func (c *Clock) Start(p plyr.Player) {
+	if c.running != 0 {
+		panic("can't start a clock while another one is running")
+	}
+	c.running, c.startedAt = p, c.now()
+}
+
+func (c *Clock) Running() plyr.Player { return c.running }
+func (c *Clock) Delay() time.Duration { return c.tc.Delay }
+
+// Stop stops the running clock, and returns whether its player ran out of time during the move.
+func (c *Clock) Stop() bool {
+	p := c.running
+	if p == 0 {
+		panic("can't stop a clock that isn't running")
+	}
+
+	elapsed := c.now().Sub(c.startedAt)
+	flagged := elapsed > c.allowance(p)
+	c.reserves[p] -= c.chargeable(elapsed)
+	c.running = 0
+	return flagged
+}
+
+// Remaining returns how much of `p`'s reserve is left, including the effect of the move currently being timed.
+func (c *Clock) Remaining(p plyr.Player) time.Duration {
+	if p != c.running {
+		return c.reserves[p]
+	}
+
+	used := c.now().Sub(c.startedAt)
+	if c.tc.DelayKind == DelayKindSimple {
+		used = c.chargeable(used)
+	}
+	return c.reserves[p] - used
+}
+
+// TimeUntilFlag returns how long the running player can still think before losing on time.
+func (c *Clock) TimeUntilFlag() time.Duration {
+	if c.running == 0 {
+		panic("no clock is running")
+	}
+	return c.allowance(c.running) - c.now().Sub(c.startedAt)
+}
+
+// allowance returns the longest that `p` may take for a move without losing on time.
+// A Bronstein delay doesn't extend it, because that delay is only added back after the move is finished.
+func (c *Clock) allowance(p plyr.Player) time.Duration {
+	if c.tc.DelayKind == DelayKindSimple {
+		return c.reserves[p] + c.tc.Delay
+	}
+	return c.reserves[p]
+}
+
+// chargeable returns how much of a move's elapsed time gets deducted from the reserve.
+// Both kinds of delay end up charging the same amount, they only differ in when a player loses on time.
+func (c *Clock) chargeable(elapsed time.Duration) time.Duration {
+	if c.tc.DelayKind == DelayKindNone {
+		return elapsed
+	} else if elapsed <= c.tc.Delay {
+		return 0
+	}
+	return elapsed - c.tc.Delay
+}
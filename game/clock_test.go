@@ -0,0 +1,55 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seriesoftubes/bgo/game/plyr"
+)
+
+func newTestClock(tc TimeControl) (*Clock, *time.Time) {
+	now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewClock(tc)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+// TestClockDelays tests how much of each player's reserve gets used up by a move, for each kind of delay.
+func TestClockDelays(t *testing.T) {
+	cases := []struct {
+		delayKind                  DelayKind
+		elapsed                    time.Duration
+		wantMidMove, wantAfterMove time.Duration // The remaining reserve halfway through the move, and after it.
+		wantFlagged                bool
+	}{
+		{DelayKindNone, 4 * time.Second, 58 * time.Second, 56 * time.Second, false},
+		{DelayKindSimple, 4 * time.Second, 60 * time.Second, 60 * time.Second, false},
+		{DelayKindSimple, 30 * time.Second, 55 * time.Second, 40 * time.Second, false},
+		{DelayKindBronstein, 4 * time.Second, 58 * time.Second, 60 * time.Second, false},
+		{DelayKindBronstein, 30 * time.Second, 45 * time.Second, 40 * time.Second, false},
+		{DelayKindNone, 61 * time.Second, 29500 * time.Millisecond, -1 * time.Second, true},
+		{DelayKindSimple, 65 * time.Second, 37500 * time.Millisecond, 5 * time.Second, false},
+		{DelayKindSimple, 71 * time.Second, 34500 * time.Millisecond, -1 * time.Second, true},
+		{DelayKindBronstein, 65 * time.Second, 27500 * time.Millisecond, 5 * time.Second, true},
+	}
+	for _, c := range cases {
+		clock, now := newTestClock(TimeControl{Reserve: time.Minute, Delay: 10 * time.Second, DelayKind: c.delayKind})
+
+		clock.Start(plyr.PCC)
+		*now = now.Add(c.elapsed / 2)
+		if got := clock.Remaining(plyr.PCC); got != c.wantMidMove {
+			t.Errorf("delay kind %d, elapsed %v: expected %v remaining mid-move but got %v", c.delayKind, c.elapsed, c.wantMidMove, got)
+		}
+		if got := clock.Remaining(plyr.PC); got != time.Minute {
+			t.Errorf("delay kind %d, elapsed %v: expected the idle player's reserve to be untouched but got %v", c.delayKind, c.elapsed, got)
+		}
+
+		*now = now.Add(c.elapsed / 2)
+		if got := clock.Stop(); got != c.wantFlagged {
+			t.Errorf("delay kind %d, elapsed %v: expected flagged=%v but got %v", c.delayKind, c.elapsed, c.wantFlagged, got)
+		}
+		if got := clock.Remaining(plyr.PCC); got != c.wantAfterMove {
+			t.Errorf("delay kind %d, elapsed %v: expected %v remaining after the move but got %v", c.delayKind, c.elapsed, c.wantAfterMove, got)
+		}
+	}
+}
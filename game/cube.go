@@ -0,0 +1,41 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/seriesoftubes/bgo/game/plyr"
+)
+
+// A Cube is the doubling cube. A player who may double offers to before they roll, and their enemy either takes, and owns the cube
+// at twice its value, or passes, and loses the game for its current value.
+type Cube struct {
+	Value uint16      // What a single game is worth.
+	Owner plyr.Player // Who may double next. 0 while the cube is centered, and either player may.
+}
+
+func NewCube() *Cube { return &Cube{Value: 1} }
+
+// MayDouble returns whether `p` may offer a double.
+func (c *Cube) MayDouble(p plyr.Player) bool { return c.Owner == 0 || c.Owner == p }
+
+// Double doubles the cube's value, and gives it to `taker`.
+func (c *Cube) Double(taker plyr.Player) {
+	c.Value *= 2
+	c.Owner = taker
+}
+
+func (c *Cube) String() string {
+	if c.Owner == 0 {
+		return fmt.Sprintf("%d, centered", c.Value)
+	}
+	return fmt.Sprintf("%d, owned by %s", c.Value, c.Owner.Symbol())
+}
+
+// Points returns how many points the winner of the game has won so far, counting the cube if the game has one.
+func (g *Game) Points() uint16 {
+	points := uint16(g.Board.WinKind())
+	if g.Cube != nil {
+		points *= g.Cube.Value
+	}
+	return points
+}
@@ -4,7 +4,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/seriesoftubes/bgo/constants"
 	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/random"
 )
 
 var (
@@ -13,27 +15,49 @@ var (
 )
 
 type Game struct {
-	ID              uint32
-	Board           *Board
-	CurrentPlayer   plyr.Player
-	CurrentRoll     Roll
-	numHumanPlayers uint8
+	ID            uint32
+	Board         *Board
+	CurrentPlayer plyr.Player
+	CurrentRoll   Roll
+	History       []HistoryEntry
+	Clock         *Clock // nil if the game isn't timed.
+	Cube          *Cube  // nil if the game is played without the doubling cube.
+	humans        map[plyr.Player]bool
+	dice          *random.Source
 }
 
-func NewGame(numHumanPlayers uint8) *Game {
-	b := &Board{}
-	b.SetUp()
-
+// NewGame sets up a new game, where `humans` lists which of the players are humans rather than computers.
+func NewGame(humans ...plyr.Player) *Game {
 	player := plyr.PCC
 	if time.Now().UnixNano()%2 == 0 {
 		player = plyr.PC
 	}
+	return newGame(player, random.NewSource(random.Int63()), humans)
+}
+
+// NewSeededGame sets up a new game whose starting player and dice rolls are all determined by `seed`.
+func NewSeededGame(seed int64, humans ...plyr.Player) *Game {
+	dice := random.NewSource(seed)
+	player := plyr.PCC
+	if dice.IntUpTo(2) == 0 {
+		player = plyr.PC
+	}
+	return newGame(player, dice, humans)
+}
+
+func newGame(player plyr.Player, dice *random.Source, humans []plyr.Player) *Game {
+	b := &Board{}
+	b.SetUp()
 
 	defer nextGameIdLock.Unlock()
 	nextGameIdLock.Lock()
 	nextGameID++
 
-	return &Game{ID: nextGameID, Board: b, CurrentPlayer: player, CurrentRoll: newRoll(), numHumanPlayers: numHumanPlayers}
+	g := &Game{ID: nextGameID, Board: b, CurrentPlayer: player, CurrentRoll: newRoll(dice), humans: map[plyr.Player]bool{}, dice: dice}
+	for _, p := range humans {
+		g.humans[p] = true
+	}
+	return g
 }
 
 func (g *Game) NextPlayersTurn() {
@@ -43,17 +67,10 @@ func (g *Game) NextPlayersTurn() {
 		g.CurrentPlayer = plyr.PCC
 	}
 
-	g.CurrentRoll = newRoll()
+	g.CurrentRoll = newRoll(g.dice)
 }
 
-func (g *Game) HasAnyHumans() bool    { return g.numHumanPlayers > 0 }
-func (g *Game) HasAnyComputers() bool { return g.numHumanPlayers < 2 }
-func (g *Game) IsCurrentPlayerHuman() bool {
-	if g.numHumanPlayers == 2 {
-		return true
-	} else if g.numHumanPlayers == 1 {
-		return g.CurrentPlayer != plyr.PC // The `PC` player is always the computer
-	} else {
-		return false
-	}
-}
+func (g *Game) HasAnyHumans() bool               { return len(g.humans) > 0 }
+func (g *Game) HasAnyComputers() bool            { return len(g.humans) < constants.NUM_PLAYERS }
+func (g *Game) IsCurrentPlayerHuman() bool       { return g.IsPlayerHuman(g.CurrentPlayer) }
+func (g *Game) IsPlayerHuman(p plyr.Player) bool { return g.humans[p] }
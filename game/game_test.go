@@ -0,0 +1,14 @@
+package game
+
+import "testing"
+
+func TestNewSeededGame(t *testing.T) {
+	g1, g2 := NewSeededGame(7), NewSeededGame(7)
+	for i := 0; i < 20; i++ {
+		if g1.CurrentPlayer != g2.CurrentPlayer || g1.CurrentRoll != g2.CurrentRoll {
+			t.Fatalf("expected games with the same seed to match on turn #%d, but got %s %v and %s %v", i, g1.CurrentPlayer.Symbol(), g1.CurrentRoll, g2.CurrentPlayer.Symbol(), g2.CurrentRoll)
+		}
+		g1.NextPlayersTurn()
+		g2.NextPlayersTurn()
+	}
+}
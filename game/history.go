@@ -0,0 +1,224 @@
+package game
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/game/turn"
+)
+
+const (
+	recordHeader     = "# bgo game"
+	recordNoMoves    = "-"
+	recordResign     = "resign"
+	recordTimeout    = "timeout"
+	recordDouble     = "double"
+	recordTake       = "take"
+	recordPass       = "pass"
+	recordAccepted   = "accepted"
+	recordDeclined   = "declined"
+	recordFieldDelim = " "
+)
+
+// A HistoryEntry records one decision made during a game: either a turn that was played, a resignation that was offered, or
+// a double that was offered before rolling. It may also record that a player ran out of time instead.
+type HistoryEntry struct {
+	Player plyr.Player
+	Roll   Roll
+	Turn   turn.Turn // Empty if the player couldn't move, or if the entry is for a resignation.
+	// Non-zero if the player offered to resign for this many points instead of playing a turn.
+	ResignationOffer    WinKind
+	ResignationAccepted bool
+	Doubled             bool // Whether the player offered a double instead of playing a turn. They play it in the next entry.
+	DoubleTaken         bool
+	TimedOut            bool
+}
+
+func (g *Game) RecordTurn(t turn.Turn) {
+	g.History = append(g.History, HistoryEntry{Player: g.CurrentPlayer, Roll: g.CurrentRoll, Turn: t})
+}
+
+func (g *Game) RecordTimeout() {
+	g.History = append(g.History, HistoryEntry{Player: g.CurrentPlayer, Roll: g.CurrentRoll, TimedOut: true})
+}
+
+func (g *Game) RecordResignation(offer WinKind, accepted bool) {
+	g.History = append(g.History, HistoryEntry{Player: g.CurrentPlayer, Roll: g.CurrentRoll, ResignationOffer: offer, ResignationAccepted: accepted})
+}
+
+func (g *Game) RecordDouble(taken bool) {
+	g.History = append(g.History, HistoryEntry{Player: g.CurrentPlayer, Roll: g.CurrentRoll, Doubled: true, DoubleTaken: taken})
+}
+
+// String serializes a HistoryEntry into a line like "X 31 X;a1;h3", "O 52 resign 2 declined", "X 64 double take" or "X 64 timeout".
+func (he HistoryEntry) String() string {
+	fields := []string{he.Player.Symbol(), fmt.Sprintf("%d%d", he.Roll[0], he.Roll[1])}
+	if he.TimedOut {
+		fields = append(fields, recordTimeout)
+	} else if he.ResignationOffer != WinKindNotWon {
+		verdict := recordDeclined
+		if he.ResignationAccepted {
+			verdict = recordAccepted
+		}
+		fields = append(fields, recordResign, strconv.Itoa(int(he.ResignationOffer)), verdict)
+	} else if he.Doubled {
+		verdict := recordPass
+		if he.DoubleTaken {
+			verdict = recordTake
+		}
+		fields = append(fields, recordDouble, verdict)
+	} else if len(he.Turn) == 0 {
+		fields = append(fields, recordNoMoves)
+	} else {
+		fields = append(fields, he.Turn.String())
+	}
+	return strings.Join(fields, recordFieldDelim)
+}
+
+func deserializeHistoryEntry(s string) (HistoryEntry, error) {
+	var he HistoryEntry
+
+	fields := strings.Fields(s)
+	if len(fields) < 3 || len(fields[0]) != 1 || len(fields[1]) != 2 {
+		return he, fmt.Errorf("invalid format of history entry %q", s)
+	}
+
+	if p := plyr.Player(fields[0][0]); p == plyr.PCC || p == plyr.PC {
+		he.Player = p
+	} else {
+		return he, fmt.Errorf("invalid player in history entry %q", s)
+	}
+
+	roll, err := ParseRoll(fields[1])
+	if err != nil {
+		return he, fmt.Errorf("invalid roll in history entry %q: %v", s, err)
+	}
+	he.Roll = roll
+
+	switch fields[2] {
+	case recordNoMoves:
+		return he, nil
+	case recordTimeout:
+		he.TimedOut = true
+		return he, nil
+	case recordResign:
+		if len(fields) != 5 {
+			return he, fmt.Errorf("invalid resignation in history entry %q", s)
+		}
+		offer, err := strconv.Atoi(fields[3])
+		if err != nil || WinKind(offer) < WinKindSingleGame || WinKind(offer) > WinKindBackgammon {
+			return he, fmt.Errorf("invalid resignation amount in history entry %q", s)
+		}
+		he.ResignationOffer = WinKind(offer)
+		he.ResignationAccepted = fields[4] == recordAccepted
+		return he, nil
+	case recordDouble:
+		if len(fields) != 4 || (fields[3] != recordTake && fields[3] != recordPass) {
+			return he, fmt.Errorf("invalid double in history entry %q", s)
+		}
+		he.Doubled, he.DoubleTaken = true, fields[3] == recordTake
+		return he, nil
+	}
+
+	t, err := turn.DeserializeTurn(fields[2])
+	if err != nil {
+		return he, fmt.Errorf("invalid turn in history entry %q: %v", s, err)
+	}
+	he.Turn = t
+	return he, nil
+}
+
+// Export writes the game's history to `w`, one decision per line.
+func (g *Game) Export(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if _, err := fmt.Fprintf(bw, "%s %d\n", recordHeader, g.ID); err != nil {
+		return fmt.Errorf("could not write header: %v", err)
+	}
+	for _, he := range g.History {
+		if _, err := fmt.Fprintln(bw, he.String()); err != nil {
+			return fmt.Errorf("could not write history entry %q: %v", he, err)
+		}
+	}
+	return bw.Flush()
+}
+
+// ImportGame reads a game history that was written by Export, and replays it from the starting position.
+// Blank lines and lines starting with "#" are ignored.
+func ImportGame(r io.Reader) (*Game, error) {
+	g := NewGame()
+	g.History = nil
+
+	sc := bufio.NewScanner(r)
+	for lineNum := 1; sc.Scan(); lineNum++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		he, err := deserializeHistoryEntry(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+		if winner := g.Board.Winner(); winner != 0 {
+			return nil, fmt.Errorf("line %d: the game was already won by %s", lineNum, winner.Symbol())
+		}
+
+		if len(g.History) == 0 {
+			g.CurrentPlayer = he.Player
+		} else if last := g.History[len(g.History)-1]; last.ResignationOffer == WinKindNotWon && !last.Doubled {
+			g.NextPlayersTurn()
+		}
+		if he.Player != g.CurrentPlayer {
+			return nil, fmt.Errorf("line %d: expected a decision by %s, but got one by %s", lineNum, g.CurrentPlayer.Symbol(), he.Player.Symbol())
+		}
+		g.CurrentRoll = he.Roll
+
+		if he.TimedOut {
+			g.RecordTimeout()
+			g.Board.Concede(he.Player, WinKindSingleGame)
+			continue
+		}
+		if he.Doubled {
+			if g.Cube == nil {
+				g.Cube = NewCube()
+			}
+			if !g.Cube.MayDouble(he.Player) {
+				return nil, fmt.Errorf("line %d: %s may not double a cube that's %v", lineNum, he.Player.Symbol(), g.Cube)
+			}
+			g.RecordDouble(he.DoubleTaken)
+			if he.DoubleTaken {
+				g.Cube.Double(he.Player.Enemy())
+			} else {
+				g.Board.Concede(he.Player.Enemy(), WinKindSingleGame)
+			}
+			continue
+		}
+		if he.ResignationOffer != WinKindNotWon {
+			g.RecordResignation(he.ResignationOffer, he.ResignationAccepted)
+			if he.ResignationAccepted {
+				g.Board.Concede(he.Player, he.ResignationOffer)
+			}
+			continue
+		}
+
+		if !he.Turn.IsValid() || !he.Turn.FitsDistances(he.Roll.MoveDistances()) {
+			return nil, fmt.Errorf("line %d: turn %v can't be played with roll %v", lineNum, he.Turn, he.Roll)
+		}
+		if len(he.Turn) > 0 && he.Turn.Player() != he.Player {
+			return nil, fmt.Errorf("line %d: turn %v contains moves for the wrong player", lineNum, he.Turn)
+		}
+		if ok, reason := g.Board.ExecuteTurnIfLegal(he.Turn); !ok {
+			return nil, fmt.Errorf("line %d: illegal turn %v: %s", lineNum, he.Turn, reason)
+		}
+		g.RecordTurn(he.Turn)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("could not read game history: %v", err)
+	}
+
+	return g, nil
+}
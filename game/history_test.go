@@ -0,0 +1,91 @@
+package game
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/seriesoftubes/bgo/game/plyr"
+)
+
+// TestImportExportGame tests that an imported game is replayed properly and can be exported again unchanged.
+func TestImportExportGame(t *testing.T) {
+	lines := []string{
+		"X 31 X;q3;s1",
+		"O 64 O;m4;x6",
+		"X 22 resign 1 declined",
+		"X 22 X;a2;a2;l2;l2",
+		"O 53 resign 2 accepted",
+	}
+
+	g, err := ImportGame(strings.NewReader("# bgo game 1\n" + strings.Join(lines, "\n") + "\n"))
+	if err != nil {
+		t.Fatalf("could not import game: %v", err)
+	}
+
+	if got := len(g.History); got != len(lines) {
+		t.Errorf("expected %d history entries but got %d", len(lines), got)
+	}
+	if winner, wk := g.Board.Winner(), g.Board.WinKind(); winner != plyr.PCC || wk != WinKindGammon {
+		t.Errorf("expected X to win a gammon by resignation, but got winner %q with %d points", string(winner), wk)
+	}
+	if pt := g.Board.Points[19]; pt.Owner != plyr.PCC || pt.NumCheckers != 2 {
+		t.Errorf("expected X to have made its 5 point, but got %v", *pt)
+	}
+
+	var buf bytes.Buffer
+	if err := g.Export(&buf); err != nil {
+		t.Fatalf("could not export game: %v", err)
+	}
+	exportedLines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if got, want := strings.Join(exportedLines[1:], "\n"), strings.Join(lines, "\n"); got != want {
+		t.Errorf("exported game doesn't match the imported one.\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestImportGameWithCube(t *testing.T) {
+	lines := []string{
+		"X 31 double take",
+		"X 31 X;q3;s1",
+		"O 64 double pass",
+	}
+	g, err := ImportGame(strings.NewReader(strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatalf("could not import game: %v", err)
+	}
+	if g.Cube == nil || g.Cube.Value != 2 || g.Cube.Owner != plyr.PC {
+		t.Errorf("expected O to own the cube at 2, but got %v", g.Cube)
+	}
+	if winner, points := g.Board.Winner(), g.Points(); winner != plyr.PC || points != 2 {
+		t.Errorf("expected O to win 2 points when X passed the redouble, but got winner %q with %d points", string(winner), points)
+	}
+
+	var buf bytes.Buffer
+	if err := g.Export(&buf); err != nil {
+		t.Fatalf("could not export game: %v", err)
+	}
+	if got, want := strings.SplitN(strings.TrimSpace(buf.String()), "\n", 2)[1], strings.Join(lines, "\n"); got != want {
+		t.Errorf("exported game doesn't match the imported one.\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestImportGameErrors tests that illegal or malformed histories can't be imported.
+func TestImportGameErrors(t *testing.T) {
+	cases := []struct {
+		desc, record string
+	}{
+		{"move from an empty point", "X 31 X;b3;s1"},
+		{"move that doesn't fit the roll", "X 31 X;q4;s1"},
+		{"same player twice", "X 31 X;q3;s1\nX 64 X;a6;a4"},
+		{"moves for the other player", "X 64 O;m4;x6"},
+		{"bad roll", "X 71 X;q3;s1"},
+		{"decision after the game ended", "X 31 resign 1 accepted\nO 64 O;m4;x6"},
+		{"double of a cube the enemy owns", "X 31 double take\nX 31 X;q3;s1\nO 64 O;m4;x6\nX 22 double take"},
+		{"malformed double", "X 31 double maybe"},
+	}
+	for _, c := range cases {
+		if _, err := ImportGame(strings.NewReader(c.record)); err == nil {
+			t.Errorf("expected an error when importing a game with a %s", c.desc)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/seriesoftubes/bgo/constants"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/game/turn"
+)
+
+const (
+	notationBar = "bar"
+	notationOff = "off"
+	notationHit = "*"
+	barPointNum = int(constants.NUM_BOARD_POINTS) + 1
+	offPointNum = 0
+)
+
+type notatedMove struct {
+	from, to int // Point numbers from the mover's point of view, where the bar is 25 and bearing off is 0.
+	hit      bool
+}
+
+// Notation describes a Turn in standard backgammon notation, like "bar/22* 13/8(2)", where points are numbered from the mover's
+// point of view. The turn must be valid for the board.
+func (b *Board) Notation(t turn.Turn) string {
+	if len(t) == 0 {
+		return ""
+	}
+
+	var moves []notatedMove
+	cop := b.Copy()
+	for _, mtp := range executionOrder(t) {
+		for i := uint8(0); i < mtp.times; i++ {
+			moves = append(moves, cop.notateMove(mtp.mo))
+			cop.ExecuteMoveUnsafe(mtp.mo)
+		}
+	}
+	sort.SliceStable(moves, func(i, j int) bool {
+		if moves[i].from != moves[j].from {
+			return moves[i].from > moves[j].from
+		}
+		return moves[i].to > moves[j].to
+	})
+
+	var parts []string
+	for i := 0; i < len(moves); {
+		n := 1
+		for i+n < len(moves) && moves[i+n] == moves[i] {
+			n++
+		}
+		part := pointNotation(moves[i].from) + "/" + pointNotation(moves[i].to)
+		if moves[i].hit {
+			part += notationHit
+		}
+		if n > 1 {
+			part += fmt.Sprintf("(%d)", n)
+		}
+		parts = append(parts, part)
+		i += n
+	}
+	return strings.Join(parts, " ")
+}
+
+// notateMove describes a single move, before it's executed.
+func (b *Board) notateMove(m turn.Move) notatedMove {
+	nm := notatedMove{from: barPointNum, to: offPointNum}
+	if !m.IsToMoveSomethingOutOfTheBar() {
+		nm.from = pointNum(m.Requestor, int(m.PointIdx()))
+	}
+	if nextIdx, onBoard := m.NextPointIdx(); onBoard {
+		nm.to = pointNum(m.Requestor, int(nextIdx))
+		pt := b.Points[nextIdx]
+		nm.hit = pt.Owner == m.Requestor.Enemy() && pt.NumCheckers == 1
+	}
+	return nm
+}
+
+// pointNum numbers the point at `pointIdx` by how far it is from bearing off, for player `p`.
+func pointNum(p plyr.Player, pointIdx int) int {
+	if p == plyr.PCC {
+		return int(constants.NUM_BOARD_POINTS) - pointIdx
+	}
+	return pointIdx + 1
+}
+
+func pointNotation(num int) string {
+	switch num {
+	case barPointNum:
+		return notationBar
+	case offPointNum:
+		return notationOff
+	}
+	return fmt.Sprint(num)
+}
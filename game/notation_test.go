@@ -0,0 +1,52 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/game/turn"
+)
+
+func TestNotation(t *testing.T) {
+	cases := []struct {
+		setUp func(b *Board)
+		turn  string
+		want  string
+	}{
+		{func(b *Board) {}, "X;q3;s1", "8/5 6/5"},
+		{func(b *Board) {}, "O;f1;h3", "8/5 6/5"},
+		{func(b *Board) {}, "X;l5;l5;s3;s3", "13/8(2) 6/3(2)"},
+		{func(b *Board) {}, "X;a6;g5", "24/18 18/13"},
+		{
+			func(b *Board) {
+				b.BarCC = 1
+				b.Points[2] = &BoardPoint{plyr.PC, 1}
+			},
+			"X;y3",
+			"bar/22*",
+		},
+		{
+			func(b *Board) {
+				for i := range b.Points {
+					b.Points[i] = &BoardPoint{}
+				}
+				b.Points[23] = &BoardPoint{plyr.PCC, 15}
+			},
+			"X;x1;x1",
+			"1/off(2)",
+		},
+	}
+	for _, c := range cases {
+		b := &Board{}
+		b.SetUp()
+		c.setUp(b)
+
+		tu, err := turn.DeserializeTurn(c.turn)
+		if err != nil {
+			t.Fatalf("bad test case %q: %v", c.turn, err)
+		}
+		if got := b.Notation(tu); got != c.want {
+			t.Errorf("expected %q to be notated as %q but got %q", c.turn, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package game
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/seriesoftubes/bgo/constants"
+	"github.com/seriesoftubes/bgo/game/plyr"
+)
+
+const (
+	positionFieldDelim = " "
+	positionPartDelim  = ":"
+	positionIDLen      = int(constants.NUM_BOARD_POINTS) + constants.NUM_PLAYERS // 1 byte per point, plus 1 per bar.
+)
+
+var positionIDEncoding = base64.RawURLEncoding
+
+// PositionText describes where the checkers are, like "a:X2 f:O5 y:X1", where "y" and "z" are the bars of X and O.
+// Checkers that aren't mentioned have been beared off.
+func (b *Board) PositionText() string {
+	var fields []string
+	for i, pt := range b.Points {
+		if pt.NumCheckers > 0 {
+			fields = append(fields, fmt.Sprintf("%c%s%s%d", constants.Num2Alpha[uint8(i)], positionPartDelim, pt.Owner.Symbol(), pt.NumCheckers))
+		}
+	}
+	if b.BarCC > 0 {
+		fields = append(fields, fmt.Sprintf("%c%s%s%d", constants.LETTER_BAR_CC, positionPartDelim, plyr.PCC.Symbol(), b.BarCC))
+	}
+	if b.BarC > 0 {
+		fields = append(fields, fmt.Sprintf("%c%s%s%d", constants.LETTER_BAR_C, positionPartDelim, plyr.PC.Symbol(), b.BarC))
+	}
+	return strings.Join(fields, positionFieldDelim)
+}
+
+// PositionID is a short, copy-pasteable version of PositionText.
+func (b *Board) PositionID() string {
+	var raw [positionIDLen]byte
+	for i, pt := range b.Points {
+		n := int8(pt.NumCheckers)
+		if pt.Owner == plyr.PC {
+			n = -n
+		}
+		raw[i] = byte(n)
+	}
+	raw[constants.NUM_BOARD_POINTS], raw[constants.NUM_BOARD_POINTS+1] = b.BarCC, b.BarC
+	return positionIDEncoding.EncodeToString(raw[:])
+}
+
+// ParsePosition creates a board from either a PositionText or a PositionID.
+func ParsePosition(s string) (*Board, error) {
+	s = strings.TrimSpace(s)
+	b := &Board{Points: &[constants.NUM_BOARD_POINTS]*BoardPoint{}}
+	for i := range b.Points {
+		b.Points[i] = &BoardPoint{}
+	}
+
+	var err error
+	if strings.Contains(s, positionPartDelim) {
+		err = b.fillFromPositionText(s)
+	} else {
+		err = b.fillFromPositionID(s)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range []plyr.Player{plyr.PCC, plyr.PC} {
+		onBoard := uint16(b.chexOnTheBar(p))
+		for _, pt := range b.Points {
+			if pt.Owner == p {
+				onBoard += uint16(pt.NumCheckers)
+			}
+		}
+		if onBoard == 0 {
+			return nil, fmt.Errorf("position %q has no checkers left for %s, so the game is over", s, p.Symbol())
+		} else if onBoard > uint16(constants.NUM_CHECKERS_PER_PLAYER) {
+			return nil, fmt.Errorf("position %q has %d checkers for %s, but the max is %d", s, onBoard, p.Symbol(), constants.NUM_CHECKERS_PER_PLAYER)
+		}
+		if off := constants.NUM_CHECKERS_PER_PLAYER - uint8(onBoard); p == plyr.PCC {
+			b.OffCC = off
+		} else {
+			b.OffC = off
+		}
+	}
+	return b, nil
+}
+
+func (b *Board) fillFromPositionText(s string) error {
+	for _, field := range strings.Fields(s) {
+		parts := strings.Split(field, positionPartDelim)
+		if len(parts) != 2 || len(parts[0]) != 1 || len(parts[1]) < 2 {
+			return fmt.Errorf("invalid part %q of position %q, should look like 'a:X2'", field, s)
+		}
+
+		p := plyr.Player(parts[1][0])
+		if p != plyr.PCC && p != plyr.PC {
+			return fmt.Errorf("invalid player in part %q of position %q", field, s)
+		}
+		n, err := strconv.Atoi(parts[1][1:])
+		if err != nil || n < 1 || n > int(constants.NUM_CHECKERS_PER_PLAYER) {
+			return fmt.Errorf("invalid number of checkers in part %q of position %q", field, s)
+		}
+
+		switch letter := parts[0][0]; {
+		case letter == constants.LETTER_BAR_CC && p == plyr.PCC:
+			b.BarCC = uint8(n)
+		case letter == constants.LETTER_BAR_C && p == plyr.PC:
+			b.BarC = uint8(n)
+		default:
+			idx, ok := constants.Alpha2Num[letter]
+			if !ok || idx >= constants.NUM_BOARD_POINTS {
+				return fmt.Errorf("invalid point in part %q of position %q", field, s)
+			}
+			if b.Points[idx].NumCheckers > 0 {
+				return fmt.Errorf("point %c appears more than once in position %q", letter, s)
+			}
+			b.Points[idx] = &BoardPoint{p, uint8(n)}
+		}
+	}
+	return nil
+}
+
+func (b *Board) fillFromPositionID(s string) error {
+	raw, err := positionIDEncoding.DecodeString(s)
+	if err != nil || len(raw) != positionIDLen {
+		return fmt.Errorf("invalid position ID %q", s)
+	}
+
+	for i := range b.Points {
+		switch n := int8(raw[i]); {
+		case n > 0:
+			b.Points[i] = &BoardPoint{plyr.PCC, uint8(n)}
+		case n < 0:
+			b.Points[i] = &BoardPoint{plyr.PC, uint8(-n)}
+		}
+	}
+	b.BarCC, b.BarC = raw[constants.NUM_BOARD_POINTS], raw[constants.NUM_BOARD_POINTS+1]
+	return nil
+}
@@ -0,0 +1,53 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/seriesoftubes/bgo/game/plyr"
+)
+
+func TestPositionRoundTrip(t *testing.T) {
+	b := &Board{}
+	b.SetUp()
+	b.Points[0].NumCheckers--
+	b.BarCC = 1
+	b.Points[5].NumCheckers -= 2
+	b.OffC = 2
+
+	if got, want := b.PositionText(), "a:X1 f:O3 h:O3 l:X5 m:O5 q:X3 s:X5 x:O2 y:X1"; got != want {
+		t.Errorf("expected the position text to be %q but got %q", want, got)
+	}
+
+	for _, s := range []string{b.PositionText(), b.PositionID()} {
+		parsed, err := ParsePosition(s)
+		if err != nil {
+			t.Fatalf("could not parse position %q: %v", s, err)
+		}
+		if !reflect.DeepEqual(parsed, b) {
+			t.Errorf("expected position %q to parse back into the same board", s)
+		}
+	}
+}
+
+func TestParsePositionErrors(t *testing.T) {
+	for _, s := range []string{
+		"",                      // Nobody has any checkers left.
+		"a:X2",                  // O has no checkers left.
+		"a:X16 x:O2",            // Too many checkers.
+		"a:X9 b:X9 x:O2",        // Too many checkers in total.
+		"a:X2 a:X3 x:O2",        // The same point twice.
+		"a:Q2 x:O2",             // Unknown player.
+		"z:X1 x:O2",             // X's checkers on O's bar.
+		"a:X2 x:O2 extra",       // Missing the colon.
+		"not an id or any text", // Neither an ID nor text.
+	} {
+		if _, err := ParsePosition(s); err == nil {
+			t.Errorf("expected an error for position %q", s)
+		}
+	}
+
+	if b, err := ParsePosition("x:O2 a:X2"); err != nil || b.OffCC != 13 || b.OffC != 13 || b.Points[0].Owner != plyr.PCC {
+		t.Errorf("expected the missing checkers to be beared off, but got %+v, %v", b, err)
+	}
+}
@@ -1,14 +1,33 @@
 package game
 
 import (
+	"fmt"
+	"strconv"
+
 	"github.com/seriesoftubes/bgo/constants"
 	"github.com/seriesoftubes/bgo/random"
 )
 
 type Roll [2]uint8
 
-func newRoll() Roll {
-	return Roll{random.Uint8Between(constants.MIN_DICE_AMT, constants.MAX_DICE_AMT), random.Uint8Between(constants.MIN_DICE_AMT, constants.MAX_DICE_AMT)}
+func newRoll(dice *random.Source) Roll {
+	return Roll{dice.Uint8Between(constants.MIN_DICE_AMT, constants.MAX_DICE_AMT), dice.Uint8Between(constants.MIN_DICE_AMT, constants.MAX_DICE_AMT)}
+}
+
+// ParseRoll reads a roll like "31".
+func ParseRoll(s string) (Roll, error) {
+	var r Roll
+	if len(s) != len(r) {
+		return r, fmt.Errorf("invalid roll %q, should look like '31'", s)
+	}
+	for i := range r {
+		amt, err := strconv.Atoi(string(s[i]))
+		if err != nil || amt < constants.MIN_DICE_AMT || amt > constants.MAX_DICE_AMT {
+			return r, fmt.Errorf("invalid roll %q, should look like '31'", s)
+		}
+		r[i] = uint8(amt)
+	}
+	return r, nil
 }
 
 func (r *Roll) MoveDistances() []uint8 {
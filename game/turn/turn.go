@@ -127,6 +127,29 @@ func (t Turn) String() string {
 	return strings.Join(out, moveDelim)
 }
 
+// Player returns the player who requested the turn's moves, or 0 if the turn has no moves.
+func (t Turn) Player() plyr.Player {
+	for m := range t {
+		return m.Requestor
+	}
+	return 0
+}
+
+// FitsDistances returns whether every move in the turn could be played with a distinct one of the given dice distances.
+func (t Turn) FitsDistances(dists []uint8) bool {
+	remaining := map[uint8]uint8{}
+	for _, d := range dists {
+		remaining[d]++
+	}
+	for m, numTimes := range t {
+		if remaining[m.FowardDistance] < numTimes {
+			return false
+		}
+		remaining[m.FowardDistance] -= numTimes
+	}
+	return true
+}
+
 func (t Turn) IsValid() bool {
 	var p plyr.Player // Placeholder for the first player listed in the turn's moves.
 	for m := range t {
@@ -0,0 +1,263 @@
+// Package bearoff contains databases with exact evaluations of bearoff positions, where both players have all of their checkers
+// in their home boards. A one-sided database knows how many rolls each position takes to bear off, and a two-sided database knows
+// the exact chance of winning when both players have few checkers left.
+package bearoff
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+const (
+	numPoints            = 6
+	numCheckersPerPlayer = 15
+	// maxRolls is how many rolls the distributions of the one-sided database go up to. Taking more rolls is too unlikely to matter,
+	// so the last one means "at least this many".
+	maxRolls = 32
+
+	MaxOneSidedCheckers     = numCheckersPerPlayer
+	DefaultTwoSidedCheckers = 6
+
+	OneSidedFileName = "bgo_bearoff_one_sided.db"
+	TwoSidedFileName = "bgo_bearoff_two_sided.db"
+)
+
+// A position is how many checkers one player has on each point of their home board, from the ace point to the 6 point.
+type position [numPoints]uint8
+
+// A roll is one of the 21 distinct rolls, and its chance.
+type roll struct {
+	d1, d2 uint8
+	chance float64
+}
+
+var rolls = func() []roll {
+	var out []roll
+	for d1 := uint8(1); d1 <= 6; d1++ {
+		for d2 := d1; d2 <= 6; d2++ {
+			chance := 2.0 / 36
+			if d1 == d2 {
+				chance = 1.0 / 36
+			}
+			out = append(out, roll{d1, d2, chance})
+		}
+	}
+	return out
+}()
+
+func (pos position) numCheckers() int {
+	var total int
+	for _, n := range pos {
+		total += int(n)
+	}
+	return total
+}
+
+func (pos position) pips() int {
+	var total int
+	for i, n := range pos {
+		total += (i + 1) * int(n)
+	}
+	return total
+}
+
+// index ranks `pos` among all positions with the same or fewer checkers. Think of the position as its checkers laid out in a row,
+// with a separator after each point's checkers. The rank is the combinatorial number of where the separators are, which doesn't
+// depend on how many checkers are borne off, so a position has the same index in the databases of every maximum number of checkers.
+func (pos position) index() int {
+	var idx, sum int
+	for j, n := range pos {
+		sum += int(n)
+		idx += binomial(sum+j, j+1)
+	}
+	return idx
+}
+
+func numPositions(maxCheckers int) int { return binomial(maxCheckers+numPoints, numPoints) }
+
+// allPositions returns every position of up to `maxCheckers` checkers, by index.
+func allPositions(maxCheckers int) []position {
+	out := make([]position, numPositions(maxCheckers))
+	var pos position
+	var fill func(pt, checkersLeft int)
+	fill = func(pt, checkersLeft int) {
+		if pt == numPoints {
+			out[pos.index()] = pos
+			return
+		}
+		for n := 0; n <= checkersLeft; n++ {
+			pos[pt] = uint8(n)
+			fill(pt+1, checkersLeft-n)
+		}
+	}
+	fill(0, maxCheckers)
+	return out
+}
+
+func binomial(n, k int) int {
+	if k < 0 || k > n {
+		return 0
+	}
+	result := 1
+	for i := 1; i <= k; i++ {
+		result = result * (n - k + i) / i
+	}
+	return result
+}
+
+// successors returns every position that `pos` can end up in after playing roll `r`.
+func successors(pos position, r roll) []position {
+	orders := [][]uint8{{r.d1, r.d2}, {r.d2, r.d1}}
+	if r.d1 == r.d2 {
+		orders = [][]uint8{{r.d1, r.d1, r.d1, r.d1}}
+	}
+
+	seen := map[position]bool{}
+	var out []position
+	var play func(p position, dice []uint8)
+	play = func(p position, dice []uint8) {
+		if len(dice) == 0 || p.numCheckers() == 0 {
+			if !seen[p] {
+				seen[p] = true
+				out = append(out, p)
+			}
+			return
+		}
+		for _, next := range playDie(p, dice[0]) {
+			play(next, dice[1:])
+		}
+	}
+	for _, dice := range orders {
+		play(pos, dice)
+	}
+	return out
+}
+
+// playDie returns every position that `pos` can end up in after moving 1 checker by `die`. With every checker at home, a die can
+// always be played: a checker can bear off from a higher point than the die only if there are no checkers above it.
+func playDie(pos position, die uint8) []position {
+	var out []position
+	highest := -1
+	for pt := numPoints - 1; pt >= 0; pt-- {
+		if pos[pt] > 0 {
+			highest = pt
+			break
+		}
+	}
+	for pt := 0; pt <= highest; pt++ {
+		if pos[pt] == 0 {
+			continue
+		}
+		dist := pt + 1
+		if dist > int(die) {
+			next := pos
+			next[pt]--
+			next[pt-int(die)]++
+			out = append(out, next)
+		} else if dist == int(die) || pt == highest {
+			next := pos
+			next[pt]--
+			out = append(out, next)
+		}
+	}
+	return out
+}
+
+// positionOf returns `p`'s bearoff position on board `b`, or false if not all of p's checkers are home.
+func positionOf(b *game.Board, p plyr.Player) (position, bool) {
+	pos, allHome := homePositionOf(b, p)
+	return pos, allHome && ((p == plyr.PC && b.BarC == 0) || (p == plyr.PCC && b.BarCC == 0))
+}
+
+// homePositionOf returns the position of the checkers in `p`'s home board on board `b`, and whether p has no checkers on the
+// rest of the board.
+func homePositionOf(b *game.Board, p plyr.Player) (position, bool) {
+	var pos position
+	allHome := true
+	homeStart, homeEnd := p.HomePointIndices()
+	for i, pt := range b.Points {
+		if pt.Owner != p || pt.NumCheckers == 0 {
+			continue
+		}
+		if uint8(i) < homeStart || uint8(i) > homeEnd {
+			allHome = false
+			continue
+		}
+		dist := i + 1 // The clockwise player's ace point is points[0].
+		if p == plyr.PCC {
+			dist = len(b.Points) - i
+		}
+		pos[dist-1] = pt.NumCheckers
+	}
+	return pos, allHome
+}
+
+// A Database evaluates bearoff positions exactly. Either of its databases can be missing.
+type Database struct {
+	oneSided *OneSided
+	twoSided *TwoSided
+}
+
+func NewDatabase(oneSided *OneSided, twoSided *TwoSided) *Database {
+	return &Database{oneSided: oneSided, twoSided: twoSided}
+}
+
+// OneSided returns the one-sided database, or nil if it's missing.
+func (db *Database) OneSided() *OneSided { return db.oneSided }
+
+// LoadDir loads whichever of the databases that are in `dir`. It returns nil if there are none.
+func LoadDir(dir string) (*Database, error) {
+	db := &Database{}
+	if f, err := os.Open(filepath.Join(dir, OneSidedFileName)); err == nil {
+		defer f.Close()
+		if db.oneSided, err = LoadOneSided(f); err != nil {
+			return nil, fmt.Errorf("could not load the one-sided bearoff database %q: %v", f.Name(), err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if f, err := os.Open(filepath.Join(dir, TwoSidedFileName)); err == nil {
+		defer f.Close()
+		if db.twoSided, err = LoadTwoSided(f); err != nil {
+			return nil, fmt.Errorf("could not load the two-sided bearoff database %q: %v", f.Name(), err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if db.oneSided == nil && db.twoSided == nil {
+		return nil, nil
+	}
+	return db, nil
+}
+
+// Probabilities returns the exact probabilities of each outcome for `p`, who's about to roll on board `b`, or false if the
+// databases don't cover the position. The two-sided database is exact. The one-sided database assumes that each player just
+// bears off as fast as they can, which is all but exact.
+func (db *Database) Probabilities(p plyr.Player, b *game.Board) (nnet.Probabilities, bool) {
+	var probs nnet.Probabilities
+	mine, ok := positionOf(b, p)
+	if !ok {
+		return probs, false
+	}
+	theirs, ok := positionOf(b, p.Enemy())
+	if !ok || mine.numCheckers() == 0 || theirs.numCheckers() == 0 {
+		return probs, false
+	}
+
+	if db.twoSided != nil {
+		if win, ok := db.twoSided.winProbability(mine, theirs); ok {
+			probs[nnet.OutputWin] = win // Gammons are impossible, since both players have borne off some checkers.
+			return probs, true
+		}
+	}
+	if db.oneSided != nil {
+		return db.oneSided.probabilities(mine, theirs)
+	}
+	return probs, false
+}
@@ -0,0 +1,159 @@
+package bearoff
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+const tolerance = 0.001
+
+func TestIndex(t *testing.T) {
+	const maxCheckers = 4
+	positions := allPositions(maxCheckers)
+	if len(positions) != 210 {
+		t.Fatalf("expected 210 positions of up to %d checkers but got %d", maxCheckers, len(positions))
+	}
+	for i, pos := range positions {
+		if pos.numCheckers() > maxCheckers || pos.index() != i {
+			t.Errorf("expected %v to have index %d but got %d", pos, i, pos.index())
+		}
+	}
+	if positions[0] != (position{}) {
+		t.Errorf("expected the empty position to be first but got %v", positions[0])
+	}
+}
+
+func TestSuccessors(t *testing.T) {
+	for _, c := range []struct {
+		pos  position
+		r    roll
+		want int
+	}{
+		{position{0, 0, 0, 0, 0, 1}, roll{d1: 1, d2: 1}, 1},  // 6 -> 2.
+		{position{0, 0, 0, 0, 0, 1}, roll{d1: 6, d2: 1}, 1},  // Off.
+		{position{1, 0, 0, 0, 0, 1}, roll{d1: 6, d2: 1}, 2},  // Both off, or 6 -> 5 -> off.
+		{position{0, 1, 0, 1, 0, 0}, roll{d1: 3, d2: 1}, 2},  // 4 -> off, or 4 -> 1 and 2 -> 1.
+		{position{0, 0, 3, 0, 0, 0}, roll{d1: 5, d2: 4}, 1},  // Only the highest checker can bear off with a bigger die.
+		{position{0, 0, 0, 0, 0, 15}, roll{d1: 2, d2: 1}, 2}, // 6 -> 3, or 6 -> 4 and 6 -> 5.
+		{position{0, 0, 0, 0, 1, 1}, roll{d1: 2, d2: 1}, 3},
+		{position{}, roll{d1: 2, d2: 1}, 1},
+	} {
+		if got := successors(c.pos, c.r); len(got) != c.want {
+			t.Errorf("expected %v to have %d positions after %d-%d but got %v", c.pos, c.want, c.r.d1, c.r.d2, got)
+		}
+	}
+}
+
+func TestOneSided(t *testing.T) {
+	o := GenerateOneSided(3)
+	for _, c := range []struct {
+		pos  position
+		want map[int]float32
+	}{
+		{position{}, map[int]float32{0: 1}},
+		{position{1, 0, 0, 0, 0, 0}, map[int]float32{1: 1}},
+		{position{0, 0, 0, 0, 0, 1}, map[int]float32{1: 27.0 / 36, 2: 9.0 / 36}}, // Only 1-1, 2-1, 3-1, 4-1 and 3-2 fall short.
+		{position{3, 0, 0, 0, 0, 0}, map[int]float32{1: 1.0 / 6, 2: 5.0 / 6}},
+	} {
+		dist := &o.offDists[c.pos.index()]
+		for n := 0; n < maxRolls; n++ {
+			if got := dist.at(n); math.Abs(float64(got-c.want[n])) > tolerance {
+				t.Errorf("expected %v to take %d rolls with a chance of %v but got %v", c.pos, n, c.want[n], got)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := o.Save(&buf); err != nil {
+		t.Fatalf("could not save: %v", err)
+	}
+	loaded, err := LoadOneSided(&buf)
+	if err != nil {
+		t.Fatalf("could not load: %v", err)
+	}
+	if err := loaded.Verify(); err != nil {
+		t.Errorf("expected the loaded database to verify: %v", err)
+	}
+	loaded.offDists[5][1]++
+	if err := loaded.Verify(); err == nil {
+		t.Errorf("expected a changed database to not verify")
+	}
+}
+
+func TestTwoSided(t *testing.T) {
+	tw := GenerateTwoSided(3)
+	for _, c := range []struct {
+		mine, theirs position
+		want         float32
+	}{
+		{position{1, 0, 0, 0, 0, 0}, position{0, 0, 0, 0, 0, 3}, 1},
+		{position{0, 0, 0, 0, 0, 1}, position{1, 0, 0, 0, 0, 0}, 27.0 / 36},
+		{position{3, 0, 0, 0, 0, 0}, position{1, 0, 0, 0, 0, 0}, 1.0 / 6},
+	} {
+		if got, ok := tw.winProbability(c.mine, c.theirs); !ok || math.Abs(float64(got-c.want)) > tolerance {
+			t.Errorf("expected %v to beat %v with a chance of %v but got %v, %v", c.mine, c.theirs, c.want, got, ok)
+		}
+	}
+	if _, ok := tw.winProbability(position{4, 0, 0, 0, 0, 0}, position{1, 0, 0, 0, 0, 0}); ok {
+		t.Errorf("expected no chance for a position with too many checkers")
+	}
+
+	var buf bytes.Buffer
+	if err := tw.Save(&buf); err != nil {
+		t.Fatalf("could not save: %v", err)
+	}
+	loaded, err := LoadTwoSided(&buf)
+	if err != nil {
+		t.Fatalf("could not load: %v", err)
+	}
+	if err := loaded.Verify(); err != nil {
+		t.Errorf("expected the loaded database to verify: %v", err)
+	}
+}
+
+func TestDatabaseProbabilities(t *testing.T) {
+	db := NewDatabase(GenerateOneSided(MaxOneSidedCheckers), GenerateTwoSided(3))
+	for _, c := range []struct {
+		pos  string
+		p    plyr.Player
+		want nnet.Probabilities
+	}{
+		{"s:X1 a:O1", plyr.PCC, nnet.Probabilities{nnet.OutputWin: 27.0 / 36}},
+		{"s:X1 a:O1", plyr.PC, nnet.Probabilities{nnet.OutputWin: 1}},
+		// Only the one-sided database has 15 checkers. X can't get them all off before O's last checker gets off in 1 roll.
+		{"x:X15 f:O1", plyr.PCC, nnet.Probabilities{nnet.OutputWin: 0, nnet.OutputLoseGammon: 0}},
+		// O's only checker is off after 1 roll, before any of X's can be.
+		{"s:X15 a:O1", plyr.PC, nnet.Probabilities{nnet.OutputWin: 1, nnet.OutputWinGammon: 1}},
+	} {
+		b, err := game.ParsePosition(c.pos)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %v", c.pos, err)
+		}
+		got, ok := db.Probabilities(c.p, b)
+		if !ok {
+			t.Errorf("expected %q to be in the database", c.pos)
+			continue
+		}
+		for k := range got {
+			if math.Abs(float64(got[k]-c.want[k])) > tolerance {
+				t.Errorf("expected %v for %v in %q but got %v", c.want, c.p, c.pos, got)
+				break
+			}
+		}
+	}
+
+	for _, pos := range []string{"a:X1 x:O1", "s:X1 a:O1 y:X1", "m:X1 a:O1"} {
+		b, err := game.ParsePosition(pos)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %v", pos, err)
+		}
+		if _, ok := db.Probabilities(plyr.PCC, b); ok {
+			t.Errorf("expected %q to not be in the database", pos)
+		}
+	}
+}
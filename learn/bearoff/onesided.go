@@ -0,0 +1,244 @@
+package bearoff
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+const oneSidedHeader = "bgo bearoff one-sided %d\n"
+
+// A distribution is the chance of taking each number of rolls, as fixed-point fractions of math.MaxUint16.
+type distribution [maxRolls]uint16
+
+func (d *distribution) at(rolls int) float32 { return float32(d[rolls]) / math.MaxUint16 }
+
+// A OneSided database knows, for every position of up to `maxCheckers` checkers, how many rolls it takes to bear off all of its
+// checkers and how many it takes to bear off the first one, when the player bears off as fast as they can.
+type OneSided struct {
+	maxCheckers       int
+	offDists          []distribution
+	firstCheckerDists []distribution
+}
+
+func (o *OneSided) MaxCheckers() int { return o.maxCheckers }
+
+// GenerateOneSided works out the database for positions of up to `maxCheckers` checkers. Positions only lead to positions with fewer
+// pips, so it goes through them by pips, and each one picks whichever move minimizes the expected number of rolls.
+func GenerateOneSided(maxCheckers int) *OneSided {
+	if maxCheckers < 1 || maxCheckers > MaxOneSidedCheckers {
+		panic(fmt.Sprintf("the one-sided bearoff database can have 1 to %d checkers, not %d", MaxOneSidedCheckers, maxCheckers))
+	}
+
+	positions := allPositions(maxCheckers)
+	order := make([]int, len(positions))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return positions[order[a]].pips() < positions[order[b]].pips() })
+
+	offDists := make([][maxRolls]float64, len(positions))
+	firstCheckerDists := make([][maxRolls]float64, len(positions))
+	offMeans := make([]float64, len(positions))
+	firstCheckerMeans := make([]float64, len(positions))
+	for _, i := range order {
+		pos := positions[i]
+		if pos.numCheckers() == 0 {
+			offDists[i][0], firstCheckerDists[i][0] = 1, 1
+			continue
+		}
+
+		hasBorneOff := pos.numCheckers() < numCheckersPerPlayer
+		if hasBorneOff {
+			firstCheckerDists[i][0] = 1
+		}
+		for _, r := range rolls {
+			bestOff, bestFirstChecker := -1, -1
+			for _, next := range successors(pos, r) {
+				ni := next.index()
+				if bestOff < 0 || offMeans[ni] < offMeans[bestOff] {
+					bestOff = ni
+				}
+				if bestFirstChecker < 0 || firstCheckerMeans[ni] < firstCheckerMeans[bestFirstChecker] {
+					bestFirstChecker = ni
+				}
+			}
+			addAfterRoll(&offDists[i], &offDists[bestOff], r.chance)
+			if !hasBorneOff {
+				addAfterRoll(&firstCheckerDists[i], &firstCheckerDists[bestFirstChecker], r.chance)
+			}
+		}
+		offMeans[i], firstCheckerMeans[i] = mean(&offDists[i]), mean(&firstCheckerDists[i])
+	}
+
+	o := &OneSided{
+		maxCheckers:       maxCheckers,
+		offDists:          make([]distribution, len(positions)),
+		firstCheckerDists: make([]distribution, len(positions)),
+	}
+	for i := range positions {
+		o.offDists[i] = toFixedPoint(&offDists[i])
+		o.firstCheckerDists[i] = toFixedPoint(&firstCheckerDists[i])
+	}
+	return o
+}
+
+// addAfterRoll adds the distribution `after` of the position after a roll, which has a chance of `chance`, to `dst`.
+func addAfterRoll(dst, after *[maxRolls]float64, chance float64) {
+	for n := 0; n < maxRolls-1; n++ {
+		dst[n+1] += chance * after[n]
+	}
+	dst[maxRolls-1] += chance * after[maxRolls-1]
+}
+
+func mean(dist *[maxRolls]float64) float64 {
+	var total float64
+	for n, p := range dist {
+		total += float64(n) * p
+	}
+	return total
+}
+
+func toFixedPoint(dist *[maxRolls]float64) distribution {
+	var out distribution
+	for n, p := range dist {
+		out[n] = uint16(math.Round(p * math.MaxUint16))
+	}
+	return out
+}
+
+// probabilities returns the probabilities of each outcome for `mine`, who's about to roll against `theirs`.
+func (o *OneSided) probabilities(mine, theirs position) (nnet.Probabilities, bool) {
+	var probs nnet.Probabilities
+	if mine.numCheckers() > o.maxCheckers || theirs.numCheckers() > o.maxCheckers {
+		return probs, false
+	}
+	myOff, theirOff := &o.offDists[mine.index()], &o.offDists[theirs.index()]
+	myFirst, theirFirst := &o.firstCheckerDists[mine.index()], &o.firstCheckerDists[theirs.index()]
+
+	// Rolling first, I win if I need at most as many rolls as them, and win a gammon if I'm done before they get a checker off.
+	// They win a gammon if they're done before I get a checker off.
+	var theirOffAtLeast, theirFirstAtLeast, myFirstAbove float32 = 1, 1, 1
+	for n := 0; n < maxRolls; n++ {
+		probs[nnet.OutputWin] += myOff.at(n) * theirOffAtLeast
+		probs[nnet.OutputWinGammon] += myOff.at(n) * theirFirstAtLeast
+		myFirstAbove -= myFirst.at(n)
+		probs[nnet.OutputLoseGammon] += theirOff.at(n) * myFirstAbove
+		theirOffAtLeast -= theirOff.at(n)
+		theirFirstAtLeast -= theirFirst.at(n)
+	}
+	for k, prob := range probs {
+		probs[k] = float32(math.Min(math.Max(float64(prob), 0), 1)) // The fixed-point chances can add up to a bit over 1.
+	}
+	return probs, true
+}
+
+// MeanRolls returns how many rolls it takes `p`, on average, to bear off the checkers in their home board on board `b`, ignoring
+// the rest of their checkers. It returns false if there are too many checkers in the home board for the database.
+func (o *OneSided) MeanRolls(p plyr.Player, b *game.Board) (float32, bool) {
+	pos, _ := homePositionOf(b, p)
+	if pos.numCheckers() > o.maxCheckers {
+		return 0, false
+	}
+	var total float32
+	dist := &o.offDists[pos.index()]
+	for n := range dist {
+		total += float32(n) * dist.at(n)
+	}
+	return total, true
+}
+
+// Save writes the database in a compact format: for each position and each of its 2 distributions, the first number of rolls
+// that's possible, how many are possible from there, and their chances.
+func (o *OneSided) Save(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if _, err := fmt.Fprintf(bw, oneSidedHeader, o.maxCheckers); err != nil {
+		return err
+	}
+	for i := range o.offDists {
+		for _, dist := range []*distribution{&o.offDists[i], &o.firstCheckerDists[i]} {
+			start, end := 0, maxRolls
+			for start < end && dist[start] == 0 {
+				start++
+			}
+			for end > start && dist[end-1] == 0 {
+				end--
+			}
+			if err := bw.WriteByte(byte(start)); err != nil {
+				return err
+			}
+			if err := bw.WriteByte(byte(end - start)); err != nil {
+				return err
+			}
+			if err := binary.Write(bw, binary.LittleEndian, dist[start:end]); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// LoadOneSided reads a database that Save wrote.
+func LoadOneSided(r io.Reader) (*OneSided, error) {
+	br := bufio.NewReader(r)
+	o := &OneSided{}
+	if _, err := fmt.Fscanf(br, oneSidedHeader, &o.maxCheckers); err != nil {
+		return nil, fmt.Errorf("not a one-sided bearoff database: %v", err)
+	}
+	if o.maxCheckers < 1 || o.maxCheckers > MaxOneSidedCheckers {
+		return nil, fmt.Errorf("invalid number of checkers %d", o.maxCheckers)
+	}
+
+	n := numPositions(o.maxCheckers)
+	o.offDists, o.firstCheckerDists = make([]distribution, n), make([]distribution, n)
+	for i := 0; i < n; i++ {
+		for _, dist := range []*distribution{&o.offDists[i], &o.firstCheckerDists[i]} {
+			var startAndLen [2]byte
+			if _, err := io.ReadFull(br, startAndLen[:]); err != nil {
+				return nil, fmt.Errorf("could not read position %d: %v", i, err)
+			}
+			start, end := int(startAndLen[0]), int(startAndLen[0])+int(startAndLen[1])
+			if end > maxRolls {
+				return nil, fmt.Errorf("invalid distribution of position %d", i)
+			}
+			if err := binary.Read(br, binary.LittleEndian, dist[start:end]); err != nil {
+				return nil, fmt.Errorf("could not read position %d: %v", i, err)
+			}
+		}
+	}
+	if _, err := br.ReadByte(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the last position")
+	}
+	return o, nil
+}
+
+// Verify regenerates the database and checks that it's the same, and that each distribution adds up to 1.
+func (o *OneSided) Verify() error {
+	const tolerance = 0.001
+	for i := range o.offDists {
+		for _, dist := range []*distribution{&o.offDists[i], &o.firstCheckerDists[i]} {
+			var total float32
+			for n := range dist {
+				total += dist.at(n)
+			}
+			if math.Abs(float64(total-1)) > tolerance {
+				return fmt.Errorf("the distribution of position %d adds up to %v", i, total)
+			}
+		}
+	}
+
+	want := GenerateOneSided(o.maxCheckers)
+	for i := range want.offDists {
+		if o.offDists[i] != want.offDists[i] || o.firstCheckerDists[i] != want.firstCheckerDists[i] {
+			return fmt.Errorf("position %d differs from a regenerated database", i)
+		}
+	}
+	return nil
+}
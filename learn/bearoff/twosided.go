@@ -0,0 +1,145 @@
+package bearoff
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+const twoSidedHeader = "bgo bearoff two-sided %d\n"
+
+// MaxTwoSidedCheckers is the most checkers that the two-sided database can have. It takes 2 bytes for every pair of positions,
+// so it grows quickly. With fewer than 15 checkers, gammons are impossible.
+const MaxTwoSidedCheckers = 10
+
+// A TwoSided database knows the exact chance of winning, for the player who's about to roll, for every pair of positions of up to
+// `maxCheckers` checkers.
+type TwoSided struct {
+	maxCheckers int
+	// winChances[i*numPositions+j] is the chance that the player in position i, who's about to roll, beats position j. They're
+	// fixed-point fractions of math.MaxUint16.
+	winChances []uint16
+}
+
+func (t *TwoSided) MaxCheckers() int { return t.maxCheckers }
+
+// GenerateTwoSided works out the database for positions of up to `maxCheckers` checkers. Every roll leads to fewer total pips,
+// so it goes through the pairs of positions by their total pips.
+func GenerateTwoSided(maxCheckers int) *TwoSided {
+	if maxCheckers < 1 || maxCheckers > MaxTwoSidedCheckers {
+		panic(fmt.Sprintf("the two-sided bearoff database can have 1 to %d checkers, not %d", MaxTwoSidedCheckers, maxCheckers))
+	}
+
+	positions := allPositions(maxCheckers)
+	n := len(positions)
+	var byPips [][]int
+	for i, pos := range positions {
+		for len(byPips) <= pos.pips() {
+			byPips = append(byPips, nil)
+		}
+		byPips[pos.pips()] = append(byPips[pos.pips()], i)
+	}
+	nexts := make([][][]int, n) // The indices of the positions after each roll.
+	for i, pos := range positions {
+		nexts[i] = make([][]int, len(rolls))
+		for ri, r := range rolls {
+			for _, next := range successors(pos, r) {
+				nexts[i][ri] = append(nexts[i][ri], next.index())
+			}
+		}
+	}
+
+	const empty = 0 // The position with every checker borne off.
+	winChances := make([]float64, n*n)
+	for totalPips := 1; totalPips <= 2*(len(byPips)-1); totalPips++ {
+		for myPips := 1; myPips < len(byPips) && myPips <= totalPips; myPips++ {
+			theirPips := totalPips - myPips
+			if theirPips >= len(byPips) {
+				continue
+			}
+			for _, i := range byPips[myPips] {
+				for _, j := range byPips[theirPips] {
+					if j == empty {
+						continue // They've already won.
+					}
+					var win float64
+					for ri, r := range rolls {
+						best := 0.0
+						for _, next := range nexts[i][ri] {
+							chance := 1.0
+							if next != empty {
+								chance = 1 - winChances[j*n+next]
+							}
+							best = math.Max(best, chance)
+						}
+						win += r.chance * best
+					}
+					winChances[i*n+j] = win
+				}
+			}
+		}
+	}
+
+	t := &TwoSided{maxCheckers: maxCheckers, winChances: make([]uint16, n*n)}
+	for k, win := range winChances {
+		t.winChances[k] = uint16(math.Round(win * math.MaxUint16))
+	}
+	return t
+}
+
+// winProbability returns the chance that `mine`, who's about to roll, beats `theirs`.
+func (t *TwoSided) winProbability(mine, theirs position) (float32, bool) {
+	if mine.numCheckers() > t.maxCheckers || theirs.numCheckers() > t.maxCheckers {
+		return 0, false
+	}
+	n := numPositions(t.maxCheckers)
+	return float32(t.winChances[mine.index()*n+theirs.index()]) / math.MaxUint16, true
+}
+
+// Save writes the database: a header, then the chance of every pair of positions.
+func (t *TwoSided) Save(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if _, err := fmt.Fprintf(bw, twoSidedHeader, t.maxCheckers); err != nil {
+		return err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, t.winChances); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// LoadTwoSided reads a database that Save wrote.
+func LoadTwoSided(r io.Reader) (*TwoSided, error) {
+	br := bufio.NewReader(r)
+	t := &TwoSided{}
+	if _, err := fmt.Fscanf(br, twoSidedHeader, &t.maxCheckers); err != nil {
+		return nil, fmt.Errorf("not a two-sided bearoff database: %v", err)
+	}
+	if t.maxCheckers < 1 || t.maxCheckers > MaxTwoSidedCheckers {
+		return nil, fmt.Errorf("invalid number of checkers %d", t.maxCheckers)
+	}
+
+	n := numPositions(t.maxCheckers)
+	t.winChances = make([]uint16, n*n)
+	if err := binary.Read(br, binary.LittleEndian, t.winChances); err != nil {
+		return nil, fmt.Errorf("could not read the chances: %v", err)
+	}
+	if _, err := br.ReadByte(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the last chance")
+	}
+	return t, nil
+}
+
+// Verify regenerates the database and checks that it's the same.
+func (t *TwoSided) Verify() error {
+	want := GenerateTwoSided(t.maxCheckers)
+	n := numPositions(t.maxCheckers)
+	for k := range want.winChances {
+		if t.winChances[k] != want.winChances[k] {
+			return fmt.Errorf("positions %d and %d differ from a regenerated database", k/n, k%n)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+// Package checkpoint saves the complete state of a training run, so that a crashed or stopped run can be resumed exactly.
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seriesoftubes/bgo/learn/trainconf"
+)
+
+const (
+	infix  = "_checkpoint_"
+	suffix = ".json"
+)
+
+// A Checkpoint is everything that's needed to continue a training run where it left off.
+type Checkpoint struct {
+	SavedAt          time.Time
+	Config           *trainconf.Config
+	StartGamesPlayed uint64 // How many games the network had been trained on when the run started.
+	GamesPlayed      uint64 // How many games the network had been trained on when the checkpoint was saved.
+	// The state of the learning rate manager, which may have been changed interactively since the run started.
+	LearningRateReductionInterval   uint64
+	LearningRateReductionMultiplier float32
+	AverageVariances                []float32 // One per game that was played in this run.
+	TotalVariances                  []float32
+	Network                         json.RawMessage // The contact neural net config, like the one that nnet.Save writes.
+	// The neural net configs of the other position classes that have their own network, by class name.
+	ClassNetworks map[string]json.RawMessage `json:",omitempty"`
+}
+
+// FilePath returns where the checkpoint of a run that writes its weights to `weightsFilePath` is saved after `gamesPlayed` games.
+// The games are zero-padded, so that checkpoints sort in the order they were saved in.
+func FilePath(weightsFilePath string, gamesPlayed uint64) string {
+	return fmt.Sprintf("%s%s%020d%s", strings.TrimSuffix(weightsFilePath, suffix), infix, gamesPlayed, suffix)
+}
+
+// Save atomically writes `c` next to `weightsFilePath`, then deletes all but the newest `numToKeep` checkpoints.
+func Save(weightsFilePath string, c *Checkpoint, numToKeep int) (string, error) {
+	if numToKeep < 1 {
+		return "", fmt.Errorf("must keep at least 1 checkpoint, but got %d", numToKeep)
+	}
+
+	filePath := FilePath(weightsFilePath, c.GamesPlayed)
+	err := WriteFileAtomically(filePath, func(w io.Writer) error {
+		if err := json.NewEncoder(w).Encode(c); err != nil {
+			return fmt.Errorf("JSON Encode error: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	filePaths, err := list(weightsFilePath)
+	if err != nil {
+		return "", err
+	}
+	for len(filePaths) > numToKeep {
+		if err := os.Remove(filePaths[0]); err != nil {
+			return "", fmt.Errorf("could not rotate out checkpoint %q: %v", filePaths[0], err)
+		}
+		filePaths = filePaths[1:]
+	}
+	return filePath, nil
+}
+
+// Latest loads the newest checkpoint of a run that writes its weights to `weightsFilePath`.
+func Latest(weightsFilePath string) (*Checkpoint, string, error) {
+	filePaths, err := list(weightsFilePath)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(filePaths) == 0 {
+		return nil, "", fmt.Errorf("no checkpoints match %q", FilePath(weightsFilePath, 0))
+	}
+
+	filePath := filePaths[len(filePaths)-1]
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not open checkpoint: %v", err)
+	}
+	defer f.Close()
+
+	var c Checkpoint
+	if err := json.NewDecoder(f).Decode(&c); err != nil {
+		return nil, "", fmt.Errorf("could not decode checkpoint %q: %v", filePath, err)
+	}
+	if c.Config == nil || len(c.Network) == 0 {
+		return nil, "", fmt.Errorf("checkpoint %q is missing its config or network", filePath)
+	}
+	return &c, filePath, nil
+}
+
+// list returns the checkpoints of a run, from oldest to newest.
+func list(weightsFilePath string) ([]string, error) {
+	prefix := strings.TrimSuffix(weightsFilePath, suffix) + infix
+	candidates, err := filepath.Glob(prefix + "*" + suffix)
+	if err != nil {
+		return nil, fmt.Errorf("could not list checkpoints: %v", err)
+	}
+
+	var filePaths []string
+	for _, fp := range candidates {
+		// Skip files like "a_checkpoint_old_checkpoint_1.json", which belong to other runs.
+		if _, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(fp, prefix), suffix), 10, 64); err == nil {
+			filePaths = append(filePaths, fp)
+		}
+	}
+	sort.Strings(filePaths)
+	return filePaths, nil
+}
+
+// WriteFileAtomically writes to a temp file in the same directory as `filePath`, then renames it to `filePath`.
+// That way, a crash never leaves behind a truncated file.
+func WriteFileAtomically(filePath string, write func(w io.Writer) error) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(filePath), filepath.Base(filePath)+".tmp")
+	if err != nil {
+		return fmt.Errorf("could not create temp file: %v", err)
+	}
+	defer os.Remove(tmp.Name()) // A no-op once the rename succeeded.
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not sync temp file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("could not close temp file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return fmt.Errorf("could not rename temp file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/seriesoftubes/bgo/learn/trainconf"
+)
+
+func TestSaveAndLatest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint_test")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	weightsFilePath := filepath.Join(dir, "nnet.json")
+
+	if _, _, err := Latest(weightsFilePath); err == nil {
+		t.Errorf("expected an error when there are no checkpoints")
+	}
+
+	// Save 9 before 10 to make sure that checkpoints are ordered by number, and not by name.
+	for _, gamesPlayed := range []uint64{9, 10, 100, 1000} {
+		c := &Checkpoint{
+			Config:           trainconf.Default(),
+			StartGamesPlayed: 5,
+			GamesPlayed:      gamesPlayed,
+			AverageVariances: []float32{0.5, float32(gamesPlayed)},
+			Network:          json.RawMessage(`{"GamesPlayedSoFar":1}`),
+		}
+		if _, err := Save(weightsFilePath, c, 2); err != nil {
+			t.Fatalf("unexpected error saving checkpoint: %v", err)
+		}
+	}
+
+	got, filePath, err := Latest(weightsFilePath)
+	if err != nil {
+		t.Fatalf("unexpected error loading checkpoint: %v", err)
+	}
+	if want := FilePath(weightsFilePath, 1000); filePath != want {
+		t.Errorf("expected the latest checkpoint to be %q but got %q", want, filePath)
+	}
+	if got.GamesPlayed != 1000 || got.StartGamesPlayed != 5 || !reflect.DeepEqual(got.AverageVariances, []float32{0.5, 1000}) {
+		t.Errorf("checkpoint didn't round trip: %+v", got)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if want := []string{FilePath(weightsFilePath, 100), FilePath(weightsFilePath, 1000)}; !reflect.DeepEqual(files, want) {
+		t.Errorf("expected only the 2 newest checkpoints to be kept, %v, but got %v", want, files)
+	}
+}
+
+func TestWriteFileAtomically(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint_test")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "out.json")
+
+	if err := WriteFileAtomically(filePath, func(w io.Writer) error { _, err := io.WriteString(w, "old"); return err }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	failing := func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return errors.New("crashed halfway")
+	}
+	if err := WriteFileAtomically(filePath, failing); err == nil {
+		t.Errorf("expected the write's error to be returned")
+	}
+
+	if text, err := ioutil.ReadFile(filePath); err != nil || string(text) != "old" {
+		t.Errorf("expected a failed write to leave the old file alone, but got %q, %v", text, err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 1 {
+		t.Errorf("expected the temp file to be cleaned up, but got %v", files)
+	}
+}
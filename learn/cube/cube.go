@@ -0,0 +1,149 @@
+// Package cube decides what to do with the doubling cube. It turns the cubeless probabilities of each outcome into cubeful
+// equities with Rick Janowski's formulas, which interpolate between a dead cube that can never be used again and a fully live one.
+package cube
+
+import (
+	"fmt"
+
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+// DefaultCubeLife is how live the cube is by default, between 0 (dead: it can never be used again) and 1 (fully live: it can
+// always be used at exactly the right moment). Real games are in between. It's the same as gnubg's default.
+const DefaultCubeLife = 0.68
+
+// epsilon is the smallest chance that the average win or loss is worked out from, to not divide by 0.
+const epsilon = 1e-7
+
+// Ownership is who owns the cube, from the POV of the player who's about to roll.
+type Ownership uint8
+
+const (
+	Centered Ownership = iota
+	OwnedByMe
+	OwnedByOpponent
+)
+
+// An Action is what the player who's about to roll should do with the cube.
+type Action uint8
+
+const (
+	NoDouble   Action = iota
+	DoubleTake        // Double, and the opponent should take.
+	DoublePass        // Double, and the opponent should pass.
+	TooGood           // Don't double, but play on for a gammon, since the opponent would pass.
+)
+
+var actionNames = []string{"no double", "double, take", "double, pass", "too good, pass"}
+
+func (a Action) String() string {
+	if int(a) < len(actionNames) {
+		return actionNames[a]
+	}
+	return fmt.Sprintf("Action(%d)", uint8(a))
+}
+
+// A Decision is what the player who's about to roll should do with the cube, and the cubeful equities behind it, in units of
+// the current cube value, or in match winning chances during a match.
+type Decision struct {
+	Action Action
+	// Take is whether the opponent should take a double, whether or not the player should double.
+	Take                             bool
+	NoDouble, DoubleTake, DoublePass float32
+	Match                            bool // Whether the equities are match winning chances.
+}
+
+func (d Decision) String() string {
+	if d.Match {
+		return fmt.Sprintf("%s (no double %.1f%%, double/take %.1f%%, double/pass %.1f%%)", d.Action, 100*d.NoDouble, 100*d.DoubleTake, 100*d.DoublePass)
+	}
+	return fmt.Sprintf("%s (no double %+.3f, double/take %+.3f, double/pass %+.3f)", d.Action, d.NoDouble, d.DoubleTake, d.DoublePass)
+}
+
+// Decide works out what the player who's about to roll with `probs` should do with the cube, which is owned by `own`, when the
+// cube is `cubeLife` live. A player can't double a cube that their opponent owns, so that's never a double.
+func Decide(probs nnet.Probabilities, own Ownership, cubeLife float32) Decision {
+	d := Decision{
+		NoDouble:   Equity(probs, own, cubeLife),
+		DoubleTake: 2 * Equity(probs, OwnedByOpponent, cubeLife),
+		DoublePass: 1,
+	}
+	d.Take = d.DoubleTake <= d.DoublePass
+	if own != OwnedByOpponent {
+		d.Action = bestAction(d)
+	}
+	return d
+}
+
+// bestAction picks the best action of decision `d`, whose equities and take are already worked out.
+func bestAction(d Decision) Action {
+	doubled := d.DoubleTake // The opponent picks whichever is worse for the doubler.
+	if !d.Take {
+		doubled = d.DoublePass
+	}
+	switch {
+	case doubled > d.NoDouble && d.Take:
+		return DoubleTake
+	case doubled > d.NoDouble:
+		return DoublePass
+	case !d.Take:
+		return TooGood
+	}
+	return NoDouble
+}
+
+// Equity is the cubeful equity, in units of the current cube value, of the player who's about to roll with `probs`, when the cube
+// is owned by `own` and `cubeLife` live. It's the cubeless equity with a dead cube, and Janowski's live cube equity with a live one.
+func Equity(probs nnet.Probabilities, own Ownership, cubeLife float32) float32 {
+	win, loss := averageWinAndLoss(probs, [3]float32{1, 2, 3}, [3]float32{1, 2, 3})
+	return equity(probs[nnet.OutputWin], win, loss, own, cubeLife)
+}
+
+// equity is the cubeful equity of a player who wins with chance `p`, and then wins `win` on average, or else loses `loss`.
+func equity(p, win, loss float32, own Ownership, cubeLife float32) float32 {
+	dead := p*win - (1-p)*loss
+	return dead*(1-cubeLife) + liveEquity(p, win, loss, own)*cubeLife
+}
+
+// averageWinAndLoss returns how much a win and a loss are worth on average, when winning or losing a single game, a gammon and
+// a backgammon are worth `winValues` and `lossValues`.
+func averageWinAndLoss(probs nnet.Probabilities, winValues, lossValues [3]float32) (float32, float32) {
+	winChances, lossChances := probs.Outcomes()
+	var win, loss float32 = 1, 1
+	if p := probs[nnet.OutputWin]; p > epsilon {
+		win = dot(winChances, winValues) / p
+	}
+	if p := 1 - probs[nnet.OutputWin]; p > epsilon {
+		loss = dot(lossChances, lossValues) / p
+	}
+	return win, loss
+}
+
+func dot(a, b [3]float32) float32 { return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] }
+
+// liveEquity is Janowski's equity with a fully live cube, which is linear in the chance of winning `p` between the take point
+// (where taking a double is worth -1) and the cash point (where doubling out is worth +1).
+func liveEquity(p, win, loss float32, own Ownership) float32 {
+	takePoint := (loss - 0.5) / (win + loss + 0.5)
+	cashPoint := (loss + 1) / (win + loss + 0.5)
+
+	switch own {
+	case OwnedByMe: // From a sure loss to the cash point, then on to a sure win.
+		if p < cashPoint {
+			return -loss + (1+loss)*p/cashPoint
+		}
+		return 1 + (win-1)*(p-cashPoint)/(1-cashPoint)
+	case OwnedByOpponent: // From a sure loss to the take point, then on to a sure win.
+		if p < takePoint {
+			return -loss + (loss-1)*p/takePoint
+		}
+		return -1 + (win+1)*(p-takePoint)/(1-takePoint)
+	default: // From a sure loss to the take point, then the cash point, then a sure win.
+		if p < takePoint {
+			return -loss + (loss-1)*p/takePoint
+		} else if p < cashPoint {
+			return -1 + 2*(p-takePoint)/(cashPoint-takePoint)
+		}
+		return 1 + (win-1)*(p-cashPoint)/(1-cashPoint)
+	}
+}
@@ -0,0 +1,54 @@
+package cube
+
+import (
+	"math"
+	"testing"
+
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+func gammonless(win float32) nnet.Probabilities { return nnet.Probabilities{nnet.OutputWin: win} }
+
+func TestEquity(t *testing.T) {
+	for _, c := range []struct {
+		probs    nnet.Probabilities
+		own      Ownership
+		cubeLife float32
+		want     float32
+	}{
+		{gammonless(0.6), Centered, 0, 0.2}, // A dead cube is the cubeless equity.
+		{nnet.Probabilities{nnet.OutputWin: 0.6, nnet.OutputWinGammon: 0.2}, OwnedByMe, 0, 0.4},
+		{gammonless(0.2), Centered, 1, -1}, // The take point of a live cube.
+		{gammonless(0.8), Centered, 1, 1},  // The cash point of a live cube.
+		{gammonless(0.5), Centered, 1, 0},
+		{gammonless(0.5), OwnedByMe, 1, 0.25},        // Halfway from -1 at 0% to +1 at the cash point.
+		{gammonless(0.5), OwnedByOpponent, 1, -0.25}, // Halfway from -1 at the take point to +1 at 100%.
+		{gammonless(0.5), OwnedByMe, 0.5, 0.125},
+	} {
+		if got := Equity(c.probs, c.own, c.cubeLife); math.Abs(float64(got-c.want)) > 1e-5 {
+			t.Errorf("expected the equity of %v with ownership %d and cube life %v to be %v but got %v", c.probs, c.own, c.cubeLife, c.want, got)
+		}
+	}
+}
+
+func TestDecide(t *testing.T) {
+	for _, c := range []struct {
+		probs    nnet.Probabilities
+		own      Ownership
+		want     Action
+		wantTake bool
+	}{
+		{gammonless(0.4), Centered, NoDouble, true},
+		{gammonless(0.6), Centered, DoubleTake, true},
+		{gammonless(0.6), OwnedByMe, DoubleTake, true},
+		{gammonless(0.8), Centered, DoublePass, false},
+		{gammonless(0.8), OwnedByOpponent, NoDouble, false}, // Only the opponent can double.
+		{nnet.Probabilities{nnet.OutputWin: 0.9, nnet.OutputWinGammon: 0.7}, Centered, TooGood, false},
+	} {
+		// With a dead cube, no double is the cubeless equity, and a double that's taken is worth twice that.
+		d := Decide(c.probs, c.own, 0)
+		if d.Action != c.want || d.Take != c.wantTake {
+			t.Errorf("expected %v to be %v with take %v but got %v", c.probs, c.want, c.wantTake, d)
+		}
+	}
+}
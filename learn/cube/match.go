@@ -0,0 +1,61 @@
+package cube
+
+import (
+	"github.com/seriesoftubes/bgo/learn/met"
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+// DecideInMatch is like Decide, but for a game of a match at score `s` with the cube at `cubeValue`, so the equities are the match
+// winning chances that `t` gives. Nobody may double in the Crawford game.
+func DecideInMatch(probs nnet.Probabilities, own Ownership, cubeLife float32, t *met.Table, s met.Score, cubeValue int) Decision {
+	d := Decision{
+		NoDouble:   MatchEquity(probs, own, cubeLife, t, s, cubeValue),
+		DoubleTake: MatchEquity(probs, OwnedByOpponent, cubeLife, t, s, 2*cubeValue),
+		DoublePass: t.MWC(s.After(cubeValue)),
+		Match:      true,
+	}
+	d.Take = d.DoubleTake <= d.DoublePass
+	if own != OwnedByOpponent && !s.Crawford {
+		d.Action = bestAction(d)
+	}
+	return d
+}
+
+// MatchEquity is the cubeful match winning chance of the player who's about to roll with `probs`, in a game of a match at score
+// `s`, when the cube is at `cubeValue`, owned by `own` and `cubeLife` live. Janowski's formulas work on a scale where winning and
+// losing a single game are +1 and -1, so the MWC of each outcome is put on that scale first. The cube is dead in the Crawford
+// game, and once it's worth enough for whoever may double next to win the match.
+func MatchEquity(probs nnet.Probabilities, own Ownership, cubeLife float32, t *met.Table, s met.Score, cubeValue int) float32 {
+	won, lost := t.MWC(s.After(cubeValue)), t.MWC(s.After(-cubeValue))
+	mid, halfRange := (won+lost)/2, (won-lost)/2
+	if halfRange < epsilon { // Nothing that happens in this game matters.
+		return mid
+	}
+	if isDead(own, s, cubeValue) {
+		cubeLife = 0
+	}
+
+	var winValues, lossValues [3]float32
+	for i := range winValues {
+		points := (i + 1) * cubeValue
+		winValues[i] = (t.MWC(s.After(points)) - mid) / halfRange
+		lossValues[i] = (mid - t.MWC(s.After(-points))) / halfRange
+	}
+	win, loss := averageWinAndLoss(probs, winValues, lossValues)
+	return mid + halfRange*equity(probs[nnet.OutputWin], win, loss, own, cubeLife)
+}
+
+// isDead returns whether a cube at `cubeValue` that's owned by `own` can never be doubled to any gain at score `s`.
+func isDead(own Ownership, s met.Score, cubeValue int) bool {
+	if s.Crawford {
+		return true
+	}
+	mine, theirs := cubeValue >= s.Away, cubeValue >= s.OpponentAway
+	switch own {
+	case OwnedByMe:
+		return mine
+	case OwnedByOpponent:
+		return theirs
+	}
+	return mine && theirs
+}
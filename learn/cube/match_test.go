@@ -0,0 +1,38 @@
+package cube
+
+import (
+	"math"
+	"testing"
+
+	"github.com/seriesoftubes/bgo/learn/met"
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+func TestDecideInMatch(t *testing.T) {
+	table := met.Default()
+	for _, c := range []struct {
+		probs nnet.Probabilities
+		s     met.Score
+		want  Action
+	}{
+		{gammonless(0.6), met.Score{Away: 1, OpponentAway: 1}, NoDouble},                                                      // Doubling gains nothing in the last game.
+		{nnet.Probabilities{nnet.OutputWin: 0.5, nnet.OutputWinGammon: 0.1}, met.Score{Away: 3, OpponentAway: 1}, DoubleTake}, // The trailer doubles after the Crawford game.
+		{gammonless(0.6), met.Score{Away: 1, OpponentAway: 3}, NoDouble},                                                      // The leader doesn't.
+		{gammonless(0.9), met.Score{Away: 1, OpponentAway: 3, Crawford: true}, NoDouble},
+		{gammonless(0.75), met.Score{Away: 2, OpponentAway: 2}, DoublePass}, // For money, that's a take.
+	} {
+		if got := DecideInMatch(c.probs, Centered, DefaultCubeLife, table, c.s, 1); got.Action != c.want {
+			t.Errorf("expected %v at %v to be %v but got %v", c.probs, c.s, c.want, got)
+		}
+	}
+}
+
+func TestMatchEquityCrawford(t *testing.T) {
+	table, s := met.Default(), met.Score{Away: 5, OpponentAway: 1, Crawford: true}
+	want := table.MatchWinningChance(gammonless(0.7), s, 1) // Nobody may double, so the cube is dead.
+	for _, own := range []Ownership{Centered, OwnedByMe, OwnedByOpponent} {
+		if got := MatchEquity(gammonless(0.7), own, DefaultCubeLife, table, s, 1); math.Abs(float64(got-want)) > 1e-6 {
+			t.Errorf("expected the MWC in the Crawford game with ownership %d to be %v but got %v", own, want, got)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package learn
+
+import (
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/learn/cube"
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+// CubeOwnership returns who owns cube `c`, from the POV of `p`.
+func CubeOwnership(c *game.Cube, p plyr.Player) cube.Ownership {
+	switch c.Owner {
+	case 0:
+		return cube.Centered
+	case p:
+		return cube.OwnedByMe
+	}
+	return cube.OwnedByOpponent
+}
+
+// CubeDecision decides what `p`, who's about to roll on board `b`, should do with cube `c` when it's `cubeLife` live, by looking
+// `ply` rolls ahead with `nets`. The decision is by match winning chances in match `m`, or for money if `m` is nil.
+func CubeDecision(nets *nnet.Set, b *game.Board, p plyr.Player, c *game.Cube, cubeLife float32, ply int, m *Match) cube.Decision {
+	probs := NewSearch(nets, ply).InMatch(m, int(c.Value)).PositionProbabilities(b, p)
+	if m == nil {
+		return cube.Decide(probs, CubeOwnership(c, p), cubeLife)
+	}
+	return cube.DecideInMatch(probs, CubeOwnership(c, p), cubeLife, m.Table, m.Score(p), int(c.Value))
+}
+
+// SetCubeLife sets how live the agent assumes the cube to be, between 0 and 1.
+func (a *Agent) SetCubeLife(cubeLife float32) { a.cubeLife = cubeLife }
+
+// OffersDouble decides whether `p`, who's about to roll on board `b`, should double cube `c`, by looking `ply` rolls ahead.
+func (a *Agent) OffersDouble(b *game.Board, p plyr.Player, c *game.Cube, ply int) bool {
+	action := CubeDecision(a.nets, b, p, c, a.cubeLife, ply, a.match).Action
+	return action == cube.DoubleTake || action == cube.DoublePass
+}
+
+// AcceptsDouble decides whether the agent should take a double of cube `c` from `doubler`, who's about to roll on board `b`.
+func (a *Agent) AcceptsDouble(b *game.Board, doubler plyr.Player, c *game.Cube, ply int) bool {
+	return CubeDecision(a.nets, b, doubler, c, a.cubeLife, ply, a.match).Take
+}
@@ -0,0 +1,95 @@
+package learn
+
+import (
+	"fmt"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/game/turn"
+	"github.com/seriesoftubes/bgo/learn/met"
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+// A Match is the state of a match that decisions get judged in, by the match winning chances (MWC) of a match equity table.
+type Match struct {
+	Table    *met.Table
+	Away     map[plyr.Player]int // How many more points each player needs to win the match.
+	Crawford bool                // Whether the current game is the Crawford game, where nobody may double.
+}
+
+// NewMatch creates a match to `length` points, where no points have been won yet.
+func NewMatch(table *met.Table, length int) *Match {
+	return &Match{Table: table, Away: map[plyr.Player]int{plyr.PCC: length, plyr.PC: length}}
+}
+
+// Score returns the score from the POV of `p`.
+func (m *Match) Score(p plyr.Player) met.Score {
+	return met.Score{Away: m.Away[p], OpponentAway: m.Away[p.Enemy()], Crawford: m.Crawford}
+}
+
+// After returns the state of the match once `winner` has won `points` in the current game.
+func (m *Match) After(winner plyr.Player, points int) *Match {
+	s := m.Score(winner).After(points)
+	return &Match{Table: m.Table, Away: map[plyr.Player]int{winner: s.Away, winner.Enemy(): s.OpponentAway}, Crawford: s.Crawford}
+}
+
+// Winner returns who has won the match, or 0 if it isn't over.
+func (m *Match) Winner() plyr.Player {
+	for _, p := range []plyr.Player{plyr.PCC, plyr.PC} {
+		if m.Away[p] <= 0 {
+			return p
+		}
+	}
+	return 0
+}
+
+func (m *Match) String() string {
+	s := fmt.Sprintf("%s is %d away, %s is %d away", plyr.PCC.Symbol(), m.Away[plyr.PCC], plyr.PC.Symbol(), m.Away[plyr.PC])
+	if sc := m.Score(plyr.PCC); sc.Crawford {
+		s += ", Crawford game"
+	} else if sc.PostCrawford() {
+		s += ", post-Crawford"
+	}
+	return s
+}
+
+// MatchWinningChance returns `p`'s MWC when p has `probs` in the current game, with the cube at `cubeValue`.
+func (m *Match) MatchWinningChance(p plyr.Player, probs nnet.Probabilities, cubeValue int) float32 {
+	return m.Table.MatchWinningChance(probs, m.Score(p), cubeValue)
+}
+
+// EquivalentEquity converts `mwc`, an amount of `p`'s MWC with the cube at `cubeValue`, into the cubeless equity that's worth as
+// much, where winning the game is worth +1 and losing it -1. That's how thresholds that are meant for equity judge MWC.
+func (m *Match) EquivalentEquity(p plyr.Player, mwc float32, cubeValue int) float32 {
+	s := m.Score(p)
+	halfRange := (m.Table.MWC(s.After(cubeValue)) - m.Table.MWC(s.After(-cubeValue))) / 2
+	if halfRange <= 0 { // Nothing that happens in this game matters.
+		return 0
+	}
+	return mwc / halfRange
+}
+
+// valuer ranks turns by their MWC in match `m` with the cube at `cubeValue`, or by cubeless equity if `m` is nil.
+func (m *Match) valuer(cubeValue int) valuer {
+	if m == nil {
+		return nil
+	}
+	return func(p plyr.Player, probs nnet.Probabilities) float32 {
+		return m.MatchWinningChance(p, probs, cubeValue)
+	}
+}
+
+// RankTurnsInMatch is like RankTurns, but turns are ranked by their MWC in match `m` with the cube at `cubeValue`, which is
+// what each RankedTurn's Equity is.
+func RankTurnsInMatch(nets *nnet.Set, b *game.Board, turns map[turn.TurnArray]turn.Turn, p plyr.Player, ply, numCandidates int, m *Match, cubeValue int) []RankedTurn {
+	ranked, _ := NewSearch(nets, ply).InMatch(m, cubeValue).rankTurns(b, turns, p, Filter{MaxCandidates: numCandidates})
+	return ranked
+}
+
+// CubeValue returns the value of cube `c`, which is 1 when the game is played without the cube.
+func CubeValue(c *game.Cube) int {
+	if c == nil {
+		return 1
+	}
+	return int(c.Value)
+}
@@ -0,0 +1,43 @@
+package learn
+
+import (
+	"math"
+	"testing"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/game/turngen"
+	"github.com/seriesoftubes/bgo/learn/met"
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+func TestMatchAfter(t *testing.T) {
+	m := NewMatch(met.Default(), 3).After(plyr.PCC, 2)
+	if m.Away[plyr.PCC] != 1 || m.Away[plyr.PC] != 3 || !m.Crawford || m.Winner() != 0 {
+		t.Errorf("expected X to be 1 away in the Crawford game, but got %v", m)
+	}
+	if m = m.After(plyr.PC, 1).After(plyr.PC, 4); m.Crawford || m.Winner() != plyr.PC {
+		t.Errorf("expected O to have won after the Crawford game, but got %v", m)
+	}
+}
+
+func TestRankTurnsInMatch(t *testing.T) {
+	nets := nnet.NewUntrainedSet()
+	b := &game.Board{}
+	b.SetUp()
+	turns := turngen.ValidTurns(b, game.Roll{3, 1}, plyr.PCC)
+
+	m := NewMatch(met.Default(), 1) // Only winning the game matters.
+	for _, rt := range RankTurnsInMatch(nets, b, turns, plyr.PCC, 0, numDeeperCandidates, m, 1) {
+		if math.Abs(float64(rt.Equity-rt.Probabilities[nnet.OutputWin])) > 1e-5 {
+			t.Errorf("expected the MWC of %v to be its chance of winning, but got %v with %v", rt.Turn, rt.Equity, rt.Probabilities)
+		}
+	}
+}
+
+func TestEquivalentEquity(t *testing.T) {
+	m := NewMatch(met.Default(), 1) // Winning the game is worth an MWC of 1, and losing it 0.
+	if got := m.EquivalentEquity(plyr.PCC, 0.1, 1); math.Abs(float64(got-0.2)) > 1e-5 {
+		t.Errorf("expected 10%% MWC to be worth 0.2 equity at double match point, but got %v", got)
+	}
+}
@@ -0,0 +1,187 @@
+package met
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	textSectionPreCrawford  = "pre-crawford"
+	textSectionPostCrawford = "post-crawford"
+	textCommentPrefix       = "#"
+)
+
+// xmlTable is a table in gnubg's XML format, which is how tables like Kazaross XG2 and Rockwell-Kazaross are usually shared.
+type xmlTable struct {
+	Name         string        `xml:"info>name"`
+	PreCrawford  xmlSubTable   `xml:"pre-crawford-table"`
+	PostCrawford []xmlSubTable `xml:"post-crawford-table"`
+}
+
+type xmlSubTable struct {
+	Type   string `xml:"type,attr"`
+	Player string `xml:"player,attr"`
+	Rows   []struct {
+		MWCs []float32 `xml:"me"`
+	} `xml:"row"`
+}
+
+// LoadFile loads a table from the file at `filePath`. See Load for the formats it can be in.
+func LoadFile(filePath string) (*Table, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the match equity table: %v", err)
+	}
+	defer f.Close()
+
+	t, err := Load(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not load the match equity table in %s: %v", filePath, err)
+	}
+	if t.Name == "" {
+		t.Name = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	}
+	return t, nil
+}
+
+// Load reads a table that's either in gnubg's XML format, with an explicit pre-Crawford table and a post-Crawford row for both
+// players, or in a plain text format. The plain text format has one row of the pre-Crawford table per line, where row i and
+// column j are the MWC of a player who's i away against one who's j away, then a "post-crawford" line, then a line with the MWC
+// of a trailer who's 1, 2, 3... away after the Crawford game. Lines that start with "#" are ignored, and MWCs may be fractions
+// or percentages, as long as the whole table uses the same. Without a post-Crawford row, the built-in table's is used.
+func Load(r io.Reader) (*Table, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var t *Table
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '<' {
+		t, err = loadXML(data)
+	} else {
+		t, err = loadText(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, t.validate()
+}
+
+func loadXML(data []byte) (*Table, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) { return input, nil } // MWCs are plain ASCII.
+	var xt xmlTable
+	if err := dec.Decode(&xt); err != nil {
+		return nil, fmt.Errorf("invalid XML: %v", err)
+	}
+
+	t := &Table{Name: xt.Name}
+	if xt.PreCrawford.Type != "explicit" {
+		return nil, fmt.Errorf("only explicit pre-Crawford tables are supported, but got type %q", xt.PreCrawford.Type)
+	}
+	for _, row := range xt.PreCrawford.Rows {
+		t.preCrawford = append(t.preCrawford, row.MWCs)
+	}
+	for _, sub := range xt.PostCrawford {
+		if sub.Player != "both" && sub.Player != "0" {
+			continue // Both players' post-Crawford MWCs are the same, so one row is enough.
+		}
+		if sub.Type != "explicit" || len(sub.Rows) != 1 {
+			return nil, fmt.Errorf("only explicit post-Crawford tables with a single row are supported")
+		}
+		t.postCrawford = sub.Rows[0].MWCs
+		break
+	}
+	if t.postCrawford == nil {
+		return nil, fmt.Errorf("no post-Crawford table")
+	}
+	return t, nil
+}
+
+func loadText(data []byte) (*Table, error) {
+	t := &Table{}
+	inPostCrawford, isPercentages := false, false
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; sc.Scan(); lineNum++ {
+		line := strings.TrimSpace(sc.Text())
+		switch strings.ToLower(line) {
+		case "":
+			continue
+		case textSectionPreCrawford:
+			inPostCrawford = false
+			continue
+		case textSectionPostCrawford:
+			inPostCrawford = true
+			continue
+		}
+		if strings.HasPrefix(line, textCommentPrefix) {
+			continue
+		}
+
+		var row []float32
+		for _, field := range strings.Fields(line) {
+			mwc, err := strconv.ParseFloat(strings.TrimSuffix(field, "%"), 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid MWC %q on line %d", field, lineNum)
+			}
+			isPercentages = isPercentages || mwc > 1 || strings.HasSuffix(field, "%")
+			row = append(row, float32(mwc))
+		}
+		if inPostCrawford && t.postCrawford != nil {
+			return nil, fmt.Errorf("more than 1 post-Crawford row on line %d", lineNum)
+		} else if inPostCrawford {
+			t.postCrawford = row
+		} else {
+			t.preCrawford = append(t.preCrawford, row)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	if isPercentages {
+		for _, row := range append(t.preCrawford, t.postCrawford) {
+			for i := range row {
+				row[i] /= 100
+			}
+		}
+	}
+	if t.postCrawford == nil {
+		t.postCrawford = Default().postCrawford
+	}
+	return t, nil
+}
+
+// validate checks that the table is square, that its post-Crawford row goes at least as far, and that every MWC is a chance.
+func (t *Table) validate() error {
+	n := len(t.preCrawford)
+	if n == 0 {
+		return fmt.Errorf("empty pre-Crawford table")
+	}
+	for i, row := range t.preCrawford {
+		if len(row) != n {
+			return fmt.Errorf("pre-Crawford row %d has %d MWCs, but the table has %d rows", i+1, len(row), n)
+		}
+	}
+	if len(t.postCrawford) < n {
+		return fmt.Errorf("the post-Crawford row has %d MWCs, but the pre-Crawford table has %d rows", len(t.postCrawford), n)
+	}
+	t.postCrawford = t.postCrawford[:n]
+
+	for _, row := range append(t.preCrawford, t.postCrawford) {
+		for _, mwc := range row {
+			if mwc < 0 || mwc > 1 {
+				return fmt.Errorf("invalid MWC %v, should be between 0 and 1", mwc)
+			}
+		}
+	}
+	return nil
+}
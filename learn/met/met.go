@@ -0,0 +1,160 @@
+// Package met contains match equity tables, which know each player's chance of winning a match from any score, and turns the
+// probabilities of each outcome of a game into match winning chances (MWC).
+package met
+
+import (
+	"fmt"
+
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+const (
+	// DefaultMaxAway is how many points away from winning the built-in table goes up to, like most published tables.
+	DefaultMaxAway = 25
+	// defaultGammonRate is the share of games that end in a gammon in the built-in table's model.
+	defaultGammonRate = 0.2
+)
+
+// A Score is the state of a match, from the POV of one player.
+type Score struct {
+	Away, OpponentAway int  // How many more points each player needs to win the match.
+	Crawford           bool // Whether the game is the Crawford game, where nobody may double.
+}
+
+// Flip returns the same score from the POV of the other player.
+func (s Score) Flip() Score {
+	return Score{Away: s.OpponentAway, OpponentAway: s.Away, Crawford: s.Crawford}
+}
+
+// PostCrawford returns whether the Crawford game has already been played, so the trailer may double again.
+func (s Score) PostCrawford() bool { return !s.Crawford && (s.Away == 1) != (s.OpponentAway == 1) }
+
+// After returns the score of the next game once the player has won `points`, or lost them if they're negative.
+// The game after the one where a player first gets to 1 away is the Crawford game.
+func (s Score) After(points int) Score {
+	next := Score{Away: s.Away, OpponentAway: s.OpponentAway}
+	if points > 0 {
+		next.Away -= points
+	} else {
+		next.OpponentAway += points
+	}
+	next.Crawford = !s.Crawford && !s.PostCrawford() && (next.Away == 1) != (next.OpponentAway == 1)
+	return next
+}
+
+func (s Score) String() string {
+	str := fmt.Sprintf("%d-away, %d-away", s.Away, s.OpponentAway)
+	if s.Crawford {
+		str += ", Crawford"
+	} else if s.PostCrawford() {
+		str += ", post-Crawford"
+	}
+	return str
+}
+
+// A Table is a match equity table.
+type Table struct {
+	Name string
+	// preCrawford[i][j] is the MWC of a player who's i+1 away against one who's j+1 away, before the Crawford game. When one of
+	// them is 1 away, it's the MWC at the start of the Crawford game.
+	preCrawford [][]float32
+	// postCrawford[i] is the MWC of a trailer who's i+1 away against a leader who's 1 away, after the Crawford game.
+	postCrawford []float32
+}
+
+// MaxAway returns how many points away from winning the table goes up to. Scores further away are treated as that far away.
+func (t *Table) MaxAway() int { return len(t.preCrawford) }
+
+// MWC returns the chance of winning the match at the start of a game at score `s`.
+func (t *Table) MWC(s Score) float32 {
+	if s.Away <= 0 {
+		return 1
+	} else if s.OpponentAway <= 0 {
+		return 0
+	}
+
+	away, oppAway := t.clamp(s.Away), t.clamp(s.OpponentAway)
+	if s.PostCrawford() && away == 1 {
+		return 1 - t.postCrawford[oppAway-1]
+	} else if s.PostCrawford() {
+		return t.postCrawford[away-1]
+	}
+	return t.preCrawford[away-1][oppAway-1]
+}
+
+func (t *Table) clamp(away int) int {
+	if away > t.MaxAway() {
+		return t.MaxAway()
+	}
+	return away
+}
+
+// MatchWinningChance returns the MWC of the player with `probs`, in a game at score `s` with the cube at `cubeValue`, where
+// nobody touches the cube again.
+func (t *Table) MatchWinningChance(probs nnet.Probabilities, s Score, cubeValue int) float32 {
+	win, lose := probs.Outcomes()
+	var mwc float32
+	for i := range win {
+		points := (i + 1) * cubeValue
+		mwc += win[i]*t.MWC(s.After(points)) + lose[i]*t.MWC(s.After(-points))
+	}
+	return mwc
+}
+
+// Default returns the built-in table. It comes from a simple model where every game ends in a gammon with the same chance,
+// the trailer doubles right after the Crawford game and the leader takes, and the cube isn't used before then. Published tables
+// like Kazaross XG2 are more accurate.
+func Default() *Table {
+	const g = defaultGammonRate
+	post := make([]float32, DefaultMaxAway)
+	postAt := func(away int) float32 { // Doubled games are worth 2 points, or 4 with a gammon.
+		if away <= 0 {
+			return 1
+		}
+		return post[away-1]
+	}
+	for i := range post {
+		if away := i + 1; away == 1 {
+			post[i] = 0.5
+		} else {
+			post[i] = 0.5 * ((1-g)*postAt(away-2) + g*postAt(away-4))
+		}
+	}
+
+	pre := make([][]float32, DefaultMaxAway)
+	for i := range pre {
+		pre[i] = make([]float32, DefaultMaxAway)
+	}
+	crawfordTrailer := func(away int) float32 { return 0.5 * ((1-g)*postAt(away-1) + g*postAt(away-2)) }
+	preAt := func(away, oppAway int) float32 {
+		switch {
+		case away <= 0:
+			return 1
+		case oppAway <= 0:
+			return 0
+		}
+		return pre[away-1][oppAway-1]
+	}
+	for sum := 2; sum <= 2*DefaultMaxAway; sum++ { // Each score only depends on scores that are closer to the end of the match.
+		for away := 1; away <= DefaultMaxAway; away++ {
+			oppAway := sum - away
+			if oppAway < 1 || oppAway > DefaultMaxAway {
+				continue
+			}
+			var mwc float32
+			switch {
+			case away == 1 && oppAway == 1:
+				mwc = 0.5
+			case away == 1:
+				mwc = 1 - crawfordTrailer(oppAway)
+			case oppAway == 1:
+				mwc = crawfordTrailer(away)
+			default:
+				mwc = 0.5*((1-g)*preAt(away-1, oppAway)+g*preAt(away-2, oppAway)) +
+					0.5*((1-g)*preAt(away, oppAway-1)+g*preAt(away, oppAway-2))
+			}
+			pre[away-1][oppAway-1] = mwc
+		}
+	}
+	return &Table{Name: "built-in", preCrawford: pre, postCrawford: post}
+}
@@ -0,0 +1,103 @@
+package met
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+func TestScoreAfter(t *testing.T) {
+	for _, c := range []struct {
+		s      Score
+		points int
+		want   Score
+	}{
+		{Score{Away: 5, OpponentAway: 3}, 2, Score{Away: 3, OpponentAway: 3}},
+		{Score{Away: 5, OpponentAway: 3}, -2, Score{Away: 5, OpponentAway: 1, Crawford: true}},
+		{Score{Away: 5, OpponentAway: 1, Crawford: true}, 1, Score{Away: 4, OpponentAway: 1}}, // Post-Crawford.
+		{Score{Away: 4, OpponentAway: 1}, 2, Score{Away: 2, OpponentAway: 1}},                 // Still post-Crawford.
+		{Score{Away: 2, OpponentAway: 1}, 1, Score{Away: 1, OpponentAway: 1}},
+	} {
+		if got := c.s.After(c.points); got != c.want {
+			t.Errorf("expected %v after winning %d points to be %v but got %v", c.s, c.points, c.want, got)
+		}
+	}
+}
+
+func TestDefault(t *testing.T) {
+	table := Default()
+	for away := 1; away <= DefaultMaxAway; away++ {
+		if got := table.MWC(Score{Away: away, OpponentAway: away}); math.Abs(float64(got-0.5)) > 1e-5 {
+			t.Errorf("expected an even score of %d-away to be 50%% but got %v", away, got)
+		}
+		for oppAway := 1; oppAway <= DefaultMaxAway; oppAway++ {
+			s := Score{Away: away, OpponentAway: oppAway, Crawford: away == 1 || oppAway == 1}
+			if sum := table.MWC(s) + table.MWC(s.Flip()); math.Abs(float64(sum-1)) > 1e-5 {
+				t.Errorf("expected the MWCs of both players at %v to add up to 1 but got %v", s, sum)
+			}
+			closer := Score{Away: away, OpponentAway: oppAway - 1, Crawford: away == 1 || oppAway == 2}
+			if oppAway > 1 && table.MWC(s) <= table.MWC(closer) {
+				t.Errorf("expected the MWC at %v to be more than when the opponent is a point closer", s)
+			}
+		}
+	}
+	if got := table.MWC(Score{Away: 2, OpponentAway: 1}); math.Abs(float64(got-0.5)) > 1e-5 {
+		t.Errorf("expected the trailer to win half the time at 2-away after the Crawford game, but got %v", got)
+	}
+}
+
+func TestMatchWinningChance(t *testing.T) {
+	table := Default()
+	probs := nnet.Probabilities{nnet.OutputWin: 0.6, nnet.OutputWinGammon: 0.2, nnet.OutputLoseGammon: 0.1}
+	for _, c := range []struct {
+		s         Score
+		cubeValue int
+		want      float32
+	}{
+		{Score{Away: 1, OpponentAway: 1}, 1, 0.6},                                   // Only winning matters.
+		{Score{Away: 2, OpponentAway: 2}, 2, 0.6},                                   // Same with the cube at 2.
+		{Score{Away: 2, OpponentAway: 2}, 1, 0.4*0.7 + 0.2 + 0.3*0.3},               // Gammons win the match.
+		{Score{Away: 5, OpponentAway: 1, Crawford: true}, 1, 0.4*0.3 + 0.2*0.3 + 0}, // Gammons don't matter for the leader.
+	} {
+		if got := table.MatchWinningChance(probs, c.s, c.cubeValue); math.Abs(float64(got-c.want)) > 1e-5 {
+			t.Errorf("expected the MWC at %v with the cube at %d to be %v but got %v", c.s, c.cubeValue, c.want, got)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	for _, c := range []struct{ name, data string }{
+		{"text", `# A 2-away table.
+50 70
+30 50
+post-crawford
+50 48`},
+		{"XML", `<?xml version = "1.0" encoding = "ISO-8859-1"?>
+<met>
+  <info><name>Tiny</name></info>
+  <pre-crawford-table type="explicit">
+    <row><me>0.5</me><me>0.7</me></row>
+    <row><me>0.3</me><me>0.5</me></row>
+  </pre-crawford-table>
+  <post-crawford-table player="both" type="explicit">
+    <row><me>0.5</me><me>0.48</me><me>0.32</me></row>
+  </post-crawford-table>
+</met>`},
+	} {
+		table, err := Load(strings.NewReader(c.data))
+		if err != nil {
+			t.Fatalf("unexpected error loading the %s table: %v", c.name, err)
+		}
+		if table.MaxAway() != 2 || table.MWC(Score{Away: 1, OpponentAway: 2, Crawford: true}) != 0.7 || table.MWC(Score{Away: 2, OpponentAway: 1}) != 0.48 {
+			t.Errorf("expected the %s table to be loaded as written, but got %+v", c.name, table)
+		}
+	}
+
+	for _, data := range []string{"", "0.5 0.7\n0.3", "0.5 x\n0.3 0.5", "0.5 0.7\n0.3 0.5\npost-crawford\n0.5"} {
+		if _, err := Load(strings.NewReader(data)); err == nil {
+			t.Errorf("expected an error loading %q", data)
+		}
+	}
+}
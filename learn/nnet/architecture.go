@@ -0,0 +1,152 @@
+package nnet
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	ActivationTanh    = "tanh"
+	ActivationReLU    = "relu"
+	ActivationSigmoid = "sigmoid"
+	ActivationLinear  = "linear"
+
+	// HeadProbabilities is 1 sigmoid output per outcome: see the `Output...` consts.
+	HeadProbabilities = "probabilities"
+	// HeadEquity is 1 linear output that estimates the cubeless equity. Networks had it before they had several outputs.
+	HeadEquity = "equity"
+
+	layerSpecDelim = ":"
+	layersDelim    = ","
+)
+
+// activations maps the name of each activation function to it, and to its derivative in terms of its output.
+var activations = map[string]activation{
+	ActivationTanh:    {tanh, func(out float32) float32 { return 1 - out*out }},
+	ActivationReLU:    {relu, func(out float32) float32 { return step(out) }},
+	ActivationSigmoid: {sigmoid, func(out float32) float32 { return out * (1 - out) }},
+	ActivationLinear:  {func(x float32) float32 { return x }, func(out float32) float32 { return 1 }},
+}
+
+type (
+	// A LayerSpec describes one fully-connected hidden layer.
+	LayerSpec struct {
+		Size       int
+		Activation string // "tanh", "relu", "sigmoid" or "linear".
+	}
+
+	// An Architecture describes the hidden layers of a network, from the inputs to the outputs, and what the outputs are.
+	Architecture struct {
+		HiddenLayers []LayerSpec
+		Head         string // HeadProbabilities or HeadEquity. Empty means HeadEquity, since that's what older networks have.
+	}
+
+	activation struct {
+		f          func(x float32) float32
+		derivative func(out float32) float32
+	}
+
+	// A layer is fully connected to the layer before it. Every node has 1 weight per input, plus 1 for the bias.
+	layer struct {
+		numIn, numOut int
+		offset        int // Where the layer's weights start in the network's weights.
+		act           activation
+	}
+)
+
+// DefaultArchitecture is 1 tanh hidden layer with 1 node per input, plus 1 for the bias, and the probabilities head.
+func DefaultArchitecture() Architecture {
+	arch := legacyArchitecture()
+	arch.Head = HeadProbabilities
+	return arch
+}
+
+// legacyArchitecture is the network that bgo used before its architecture was configurable.
+func legacyArchitecture() Architecture {
+	return Architecture{HiddenLayers: []LayerSpec{{Size: numInputs + 1, Activation: ActivationTanh}}, Head: HeadEquity}
+}
+
+// ParseArchitecture reads hidden layers like "128:tanh,64:relu", for a network with the probabilities head.
+func ParseArchitecture(s string) (Architecture, error) {
+	arch := Architecture{Head: HeadProbabilities}
+	for _, ls := range strings.Split(s, layersDelim) {
+		parts := strings.Split(strings.TrimSpace(ls), layerSpecDelim)
+		if len(parts) != 2 {
+			return arch, fmt.Errorf("invalid layer %q in architecture %q, should look like '128:tanh'", ls, s)
+		}
+		size, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return arch, fmt.Errorf("invalid layer size %q in architecture %q: %v", parts[0], s, err)
+		}
+		arch.HiddenLayers = append(arch.HiddenLayers, LayerSpec{Size: size, Activation: parts[1]})
+	}
+	return arch, arch.Validate()
+}
+
+func (a Architecture) String() string {
+	var layers []string
+	for _, ls := range a.HiddenLayers {
+		layers = append(layers, fmt.Sprintf("%d%s%s", ls.Size, layerSpecDelim, ls.Activation))
+	}
+	if a.head() == HeadEquity {
+		return strings.Join(layers, layersDelim) + " with the equity head"
+	}
+	return strings.Join(layers, layersDelim)
+}
+
+func (a Architecture) Equal(other Architecture) bool { return a.String() == other.String() }
+
+func (a Architecture) head() string {
+	if a.Head == "" {
+		return HeadEquity
+	}
+	return a.Head
+}
+
+func (a Architecture) Validate() error {
+	for i, ls := range a.HiddenLayers {
+		if ls.Size < 1 {
+			return fmt.Errorf("hidden layer #%d must have at least 1 node, but got %d", i+1, ls.Size)
+		}
+		if _, ok := activations[ls.Activation]; !ok {
+			return fmt.Errorf("hidden layer #%d has unknown activation %q, should be %q, %q, %q or %q", i+1, ls.Activation, ActivationTanh, ActivationReLU, ActivationSigmoid, ActivationLinear)
+		}
+	}
+	if h := a.head(); h != HeadProbabilities && h != HeadEquity {
+		return fmt.Errorf("unknown head %q, should be %q or %q", a.Head, HeadProbabilities, HeadEquity)
+	}
+	return nil
+}
+
+// layers lays out the architecture's layers, including the output layer, and returns how many weights they have in total.
+// The output layer has NumOutputs sigmoid nodes, or 1 linear node for the equity head.
+func (a Architecture) layers() ([]layer, int) {
+	var out []layer
+	numIn, offset := numInputs, 0
+	add := func(numOut int, act activation) {
+		out = append(out, layer{numIn: numIn, numOut: numOut, offset: offset, act: act})
+		offset += numOut * (numIn + 1)
+		numIn = numOut
+	}
+	for _, ls := range a.HiddenLayers {
+		add(ls.Size, activations[ls.Activation])
+	}
+	if a.head() == HeadEquity {
+		add(1, activations[ActivationLinear])
+	} else {
+		add(NumOutputs, activations[ActivationSigmoid])
+	}
+	return out, offset
+}
+
+func sigmoid(x float32) float32 { return float32(1.0 / (1.0 + math.Exp(float64(-x)))) }
+func tanh(x float32) float32    { return float32(math.Tanh(float64(x))) }
+func relu(x float32) float32    { return float32(math.Max(0, float64(x))) }
+func step(x float32) float32 {
+	if x > 0 {
+		return 1
+	}
+	return 0
+}
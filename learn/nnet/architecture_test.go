@@ -0,0 +1,118 @@
+package nnet
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/state"
+)
+
+func TestParseArchitecture(t *testing.T) {
+	arch, err := ParseArchitecture("128:tanh, 64:relu")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (Architecture{HiddenLayers: []LayerSpec{{128, ActivationTanh}, {64, ActivationReLU}}, Head: HeadProbabilities}); !arch.Equal(want) {
+		t.Errorf("expected %v but got %v", want, arch)
+	}
+	if arch.String() != "128:tanh,64:relu" {
+		t.Errorf("expected the string to round-trip, but got %q", arch.String())
+	}
+
+	for _, s := range []string{"", "128", "0:tanh", "x:tanh", "128:swish", "128:tanh,"} {
+		if _, err := ParseArchitecture(s); err == nil {
+			t.Errorf("expected an error parsing %q", s)
+		}
+	}
+}
+
+func TestLoadConvertsLegacyWeights(t *testing.T) {
+	b := &game.Board{}
+	b.SetUp()
+	st := state.DetectState(plyr.PCC, b)
+
+	// The old network: 1 tanh layer whose last node was a bias node that always output tanh(bias).
+	numHidden := numInputs + 1
+	in2fh, fh2out := make([]float32, numHidden*(numInputs+1)), make([]float32, numHidden+1)
+	for i := range in2fh {
+		in2fh[i] = float32(i%7-3) / 100
+	}
+	for i := range fh2out {
+		fh2out[i] = float32(i%5-2) / 10
+	}
+	var want float64
+	for j := 0; j < numHidden; j++ {
+		row := in2fh[j*(numInputs+1) : (j+1)*(numInputs+1)]
+		var sum float32
+		for i, x := range st {
+			sum += x * row[i]
+		}
+		sum += bias * row[numInputs]
+		want += float64(tanh(sum) * fh2out[j])
+	}
+	want += float64(tanh(bias) * fh2out[numHidden])
+
+	text, _ := json.Marshal(map[string]interface{}{"GamesPlayedSoFar": 3, "In2FhWeights": in2fh, "Fh2OutWeights": fh2out})
+	n := New()
+	if _, err := n.Load(bytes.NewReader(text)); err != nil {
+		t.Fatalf("unexpected error loading: %v", err)
+	}
+	if !n.Architecture().Equal(legacyArchitecture()) {
+		t.Errorf("expected the legacy architecture but got %v", n.Architecture())
+	}
+	if got := n.ValueEstimate(st); math.Abs(float64(got)-want) > 1e-4 {
+		t.Errorf("expected the converted network to estimate %v like the old one, but got %v", want, got)
+	}
+}
+
+func TestGradientMatchesNumericalDerivative(t *testing.T) {
+	b := &game.Board{}
+	b.SetUp()
+	st := state.DetectState(plyr.PC, b)
+
+	arch, _ := ParseArchitecture("6:tanh,4:sigmoid,3:relu")
+	n, err := NewWithArchitecture(arch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	const eps = 1e-2
+	for k := 0; k < NumOutputs; k++ {
+		grad := make([]float32, len(n.weights))
+		n.gradient(n.forward(&st), k, grad)
+
+		for _, i := range []int{0, 5, len(n.weights) / 2, len(n.weights) - 40, len(n.weights) - 1} {
+			orig := n.weights[i]
+			n.weights[i] = orig + eps
+			up := n.outputs(&st)[k]
+			n.weights[i] = orig - eps
+			down := n.outputs(&st)[k]
+			n.weights[i] = orig
+
+			if numerical := (up - down) / (2 * eps); math.Abs(float64(numerical-grad[i])) > 1e-2 {
+				t.Errorf("output #%d, weight #%d: expected a gradient of about %v but got %v", k, i, numerical, grad[i])
+			}
+		}
+	}
+}
+
+func TestSaveAndLoadArchitecture(t *testing.T) {
+	arch, _ := ParseArchitecture("8:relu")
+	saved, _ := NewWithArchitecture(arch)
+	var buf bytes.Buffer
+	if err := saved.Save(&buf, 1, true); err != nil {
+		t.Fatalf("unexpected error saving: %v", err)
+	}
+
+	n := New()
+	if _, err := n.Load(&buf); err != nil {
+		t.Fatalf("unexpected error loading: %v", err)
+	}
+	if !n.Architecture().Equal(arch) {
+		t.Errorf("expected the architecture %v to be loaded, but got %v", arch, n.Architecture())
+	}
+}
@@ -0,0 +1,119 @@
+package nnet
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/seriesoftubes/bgo/constants"
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+)
+
+const (
+	// DefaultCacheSize is how many evaluations a set's cache holds by default, which takes about 8MB.
+	DefaultCacheSize = 1 << 17
+	numCacheLocks    = 256 // Each lock guards every 256th entry, so that goroutines rarely wait for each other.
+
+	fnvOffsetBasis = 14695981039346656037
+	fnvPrime       = 1099511628211
+	cacheKeyEnemy  = 0x80 // Marks the points that are owned by PC in a cache key.
+)
+
+// A cacheKey identifies a position along with the player who's about to roll in it: the checkers on each point, with
+// cacheKeyEnemy set on PC's points, then both bars, both bearoffs, and the player.
+type cacheKey [constants.NUM_BOARD_POINTS + 5]uint8
+
+type cacheEntry struct {
+	key     cacheKey
+	version uint64 // The version of the weights that the entry was evaluated with. 0 means that the entry is empty.
+	probs   Probabilities
+	equity  float32
+}
+
+// A Cache remembers the most recent evaluations of the positions that hash to each of its entries, so that searches which reach
+// the same position many times only evaluate it once. Every evaluation is tagged with the version of the weights that made it,
+// and stops being used as soon as the weights change. It's safe to use from multiple goroutines.
+type Cache struct {
+	hits, misses uint64 // Accessed atomically, so they come first to stay 64-bit aligned.
+	entries      []cacheEntry
+	locks        [numCacheLocks]sync.Mutex
+}
+
+// CacheStats counts the lookups into a cache.
+type CacheStats struct {
+	Hits, Misses uint64
+}
+
+// NewCache creates a cache that holds up to `size` evaluations.
+func NewCache(size int) *Cache {
+	if size < 1 {
+		size = 1
+	}
+	return &Cache{entries: make([]cacheEntry, size)}
+}
+
+func newCacheKey(p plyr.Player, b *game.Board) cacheKey {
+	var k cacheKey
+	for i, pt := range b.Points {
+		k[i] = pt.NumCheckers
+		if pt.Owner == plyr.PC {
+			k[i] |= cacheKeyEnemy
+		}
+	}
+	n := len(b.Points)
+	k[n], k[n+1], k[n+2], k[n+3], k[n+4] = b.BarCC, b.BarC, b.OffCC, b.OffC, uint8(p)
+	return k
+}
+
+// hash is the 64-bit FNV-1a hash of the key.
+func (k *cacheKey) hash() uint64 {
+	h := uint64(fnvOffsetBasis)
+	for _, c := range k {
+		h ^= uint64(c)
+		h *= fnvPrime
+	}
+	return h
+}
+
+// get returns the evaluation of key `k` by the weights at `version`, if the cache has it.
+func (c *Cache) get(k cacheKey, version uint64) (Probabilities, float32, bool) {
+	idx := k.hash() % uint64(len(c.entries))
+	mu := &c.locks[idx%numCacheLocks]
+	mu.Lock()
+	e := c.entries[idx]
+	mu.Unlock()
+
+	if e.version != version || e.key != k {
+		atomic.AddUint64(&c.misses, 1)
+		return Probabilities{}, 0, false
+	}
+	atomic.AddUint64(&c.hits, 1)
+	return e.probs, e.equity, true
+}
+
+// put remembers the evaluation of key `k` by the weights at `version`, replacing whatever the entry held before.
+func (c *Cache) put(k cacheKey, version uint64, probs Probabilities, equity float32) {
+	idx := k.hash() % uint64(len(c.entries))
+	mu := &c.locks[idx%numCacheLocks]
+	mu.Lock()
+	c.entries[idx] = cacheEntry{key: k, version: version, probs: probs, equity: equity}
+	mu.Unlock()
+}
+
+// Stats returns how many lookups found an evaluation, and how many didn't, since the cache was created.
+func (c *Cache) Stats() CacheStats {
+	return CacheStats{Hits: atomic.LoadUint64(&c.hits), Misses: atomic.LoadUint64(&c.misses)}
+}
+
+// HitRate returns the share of lookups that found an evaluation, or 0 if there weren't any.
+func (s CacheStats) HitRate() float64 {
+	if total := s.Hits + s.Misses; total > 0 {
+		return float64(s.Hits) / float64(total)
+	}
+	return 0
+}
+
+func (s CacheStats) String() string {
+	return fmt.Sprintf("%d lookups, %.1f%% hits", s.Hits+s.Misses, 100*s.HitRate())
+}
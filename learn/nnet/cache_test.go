@@ -0,0 +1,65 @@
+package nnet
+
+import (
+	"testing"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/state"
+)
+
+func TestSetCachesEvaluations(t *testing.T) {
+	s := NewUntrainedSet()
+	b := &game.Board{}
+	b.SetUp()
+
+	want := s.Net(state.ClassContact).Evaluate(state.DetectState(plyr.PCC, b))
+	for i := 0; i < 2; i++ {
+		if got := s.Evaluate(plyr.PCC, b); got != want {
+			t.Errorf("expected evaluation #%d to be %v but got %v", i+1, want, got)
+		}
+	}
+	if got := s.CacheStats(); got != (CacheStats{Hits: 1, Misses: 1}) {
+		t.Errorf("expected the 2nd evaluation to come from the cache, but got %v", got)
+	}
+
+	s.Evaluate(plyr.PC, b)
+	if got := s.CacheStats(); got.Misses != 2 {
+		t.Errorf("expected the same board with the other player on roll to miss the cache, but got %v", got)
+	}
+}
+
+func TestCacheForgetsChangedWeights(t *testing.T) {
+	s := NewUntrainedSet()
+	b := &game.Board{}
+	b.SetUp()
+
+	before := s.Evaluate(plyr.PCC, b)
+	s.Net(state.ClassContact).SetLearningParams(0.1, 0)
+	s.TrainWeights(1, plyr.PCC, b, WonWith(game.WinKindGammon))
+
+	want := s.Net(state.ClassContact).Evaluate(state.DetectState(plyr.PCC, b))
+	if want == before {
+		t.Fatalf("expected training to change the evaluation")
+	}
+	if got := s.Evaluate(plyr.PCC, b); got != want {
+		t.Errorf("expected the evaluation after training to be %v but got %v", want, got)
+	}
+	if got := s.CacheStats(); got.Hits != 0 {
+		t.Errorf("expected no evaluation to come from the cache, but got %v", got)
+	}
+}
+
+func TestCacheKeys(t *testing.T) {
+	b := &game.Board{}
+	b.SetUp()
+	bcop := b.Copy()
+	if newCacheKey(plyr.PCC, b) != newCacheKey(plyr.PCC, bcop) {
+		t.Errorf("expected copies of a board to have the same key")
+	}
+
+	bcop.BarC++
+	if newCacheKey(plyr.PCC, b) == newCacheKey(plyr.PCC, bcop) {
+		t.Errorf("expected boards with different bars to have different keys")
+	}
+}
@@ -1,83 +1,131 @@
-// Package nnet contains a neural network that estimates the game equity given the current state of the game.
-// The network itself consists of the input layer, 1 fully-connected Sigmoid layer.
-// TODO: maybe add 2nd hidden ReLU layer.
+// Package nnet contains a neural network that estimates the probabilities of each outcome of the game given the current state of the game.
+// The network consists of the input layer, any number of fully-connected hidden layers, and 1 sigmoid output per outcome.
+// It learns by TD(lambda), with an eligibility trace for every weight and output.
 package nnet
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"math"
+	"os"
 	"runtime"
 	"sync"
+	"sync/atomic"
 
 	"github.com/seriesoftubes/bgo/random"
 	"github.com/seriesoftubes/bgo/state"
 )
 
 const (
-	bias                 = float32(1.8)           // If you change the bias, saved weights will be erroneous and you need to retrain the network.
-	numInputs            = len(state.State{}) + 1 // The `+1` is for the artificially-added bias.
-	numIn2FhConnections  = numInputs * numInputs  // the "FH" aka firsthidden layer is fully-connected with all inputs.
-	numFhNodes           = numInputs + 1          // the "FH" layer has 1 node per input, plus one more bias.
-	numFh2OutConnections = numFhNodes             // Each "FH" node connects directly to the 1 output signal.
+	bias      = float32(1.8) // Every layer gets this as an extra input. If you change the bias, saved weights will be erroneous and you need to retrain the network.
+	numInputs = len(state.State{})
+
+	defaultLearningRate         = float32(0.00001)
+	defaultEligibilityDecayRate = float32(0.95)
 )
 
 var (
-	// TODO: automatically skip modifying certain weights to keep it closer to "all else equal".
-	learningRate         = float32(0.00001)
-	eligibilityDecayRate = float32(0.95)
-	configMu             sync.RWMutex
-
 	maxConcurrentGames int = runtime.NumCPU() * 2 // Assume a max of 2 goroutines training per CPU. This variable would be a const but that caused a compiler error.
+	maxWeightChunks    int = runtime.NumCPU()*3/2 + 1
 
-	bigassArrays chan *[numIn2FhConnections]float32 = func() chan *[numIn2FhConnections]float32 {
-		num := maxConcurrentGames * 3
-		ch := make(chan *[numIn2FhConnections]float32, num)
-		for i := 0; i < num; i++ {
-			var arr [numIn2FhConnections]float32
-			ch <- &arr
-		}
-		return ch
-	}()
-
-	// Weights that connect IN to FH.
-	in2fhWeightsPtr *[numIn2FhConnections]float32 = (func() *[numIn2FhConnections]float32 {
-		out := [numIn2FhConnections]float32{}
-		for i := 0; i < numIn2FhConnections; i++ {
-			out[i] = random.Float32Between(-1, 1)
-		}
-		return &out
-	})()
-	// each key in the map is a gameID, and each value is an array of previous eligibility traces-- one trace for each in2fh weight.
-	in2fhWeightsPreviousEligibilityTracesByGameID map[uint32]*[numIn2FhConnections]float32 = make(map[uint32]*[numIn2FhConnections]float32, maxConcurrentGames)
-	in2fhWeightsMu                                sync.RWMutex
-	in2fhWeightsChunked                           []int = splitIntoChunkSizes(numIn2FhConnections, runtime.NumCPU()*3/2)
-
-	// Weights that connect FH to OUT.
-	fh2outWeights [numFh2OutConnections]float32 = (func() [numFh2OutConnections]float32 {
-		out := [numFh2OutConnections]float32{}
-		for i := 0; i < numFh2OutConnections; i++ {
-			out[i] = random.Float32Between(-1, 1)
-		}
-		return out
-	})()
-	// each key in the map is a gameID, and each value is an array of previous eligibility traces-- one trace for each fh2out weight.
-	fh2outWeightsPreviousEligibilityTracesByGameID map[uint32]*[numFh2OutConnections]float32 = make(map[uint32]*[numFh2OutConnections]float32, maxConcurrentGames)
-	fh2outWeightsMu                                sync.RWMutex
+	// latestWeightsVersion is the version of the latest change to any network's weights. Every change gets a new version, so
+	// that a network that replaces another never has the same version.
+	latestWeightsVersion uint64
 )
 
-type netConfig struct {
-	GamesPlayedSoFar     uint64
-	LearningRate         float32
-	EligibilityDecayRate float32
-	In2FhWeights         [numIn2FhConnections]float32
-	Fh2OutWeights        [numFh2OutConnections]float32
+type (
+	// A Network estimates the equity of a state, and learns from the games it's trained on. It's safe to use from multiple goroutines.
+	Network struct {
+		// TODO: automatically skip modifying certain weights to keep it closer to "all else equal".
+		learningRate         float32
+		eligibilityDecayRate float32
+		configMu             sync.RWMutex
+
+		arch   Architecture
+		layers []layer
+		// The weights of every layer, from the first hidden layer to the output. Each layer's weights are 1 row per node, with the bias weight last.
+		weights      []float32
+		weightChunks []int // How to split up the weights when updating them in parallel.
+		// each key in the map is a gameID, and each value holds the previous eligibility traces-- one trace for each output and weight,
+		// output by output.
+		previousEligibilityTracesByGameID map[uint32][]float32
+		version                           uint64 // Changes every time the weights do, so that cached evaluations can tell they're stale.
+		weightsMu                         sync.RWMutex
+
+		zeroedArrays sync.Pool // Slices that are all zeroes and have 1 element per output and weight, for gradients and traces.
+	}
+
+	netConfig struct {
+		GamesPlayedSoFar     uint64
+		LearningRate         float32
+		EligibilityDecayRate float32
+		Architecture         *Architecture
+		Weights              []float32
+
+		// Files from before the architecture was configurable only have these, for the default architecture.
+		In2FhWeights  []float32 `json:",omitempty"`
+		Fh2OutWeights []float32 `json:",omitempty"`
+	}
+)
+
+// New creates an untrained network with the default architecture and random weights.
+func New() *Network {
+	n, err := NewWithArchitecture(DefaultArchitecture())
+	if err != nil {
+		panic("the default architecture is invalid: " + err.Error())
+	}
+	return n
+}
+
+// NewWithArchitecture creates an untrained network with random weights.
+func NewWithArchitecture(arch Architecture) (*Network, error) {
+	if err := arch.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Each layer's weights are scaled down by its number of inputs, so that its sums start out small enough to not saturate a sigmoid.
+	layers, numWeights := arch.layers()
+	weights := make([]float32, numWeights)
+	for _, l := range layers {
+		scale := float32(1 / math.Sqrt(float64(l.numIn+1)))
+		for i := l.offset; i < l.offset+l.numOut*(l.numIn+1); i++ {
+			weights[i] = scale * random.Float32Between(-1, 1)
+		}
+	}
+	n := &Network{learningRate: defaultLearningRate, eligibilityDecayRate: defaultEligibilityDecayRate}
+	n.setWeights(arch, weights)
+	return n, nil
+}
+
+// setWeights replaces the architecture and weights, and forgets all eligibility traces. The caller must hold weightsMu, unless
+// nobody else can use the network yet.
+func (n *Network) setWeights(arch Architecture, weights []float32) {
+	n.arch = arch
+	n.layers, _ = arch.layers()
+	n.weights = weights
+	n.weightChunks = splitIntoChunkSizes(len(weights), maxWeightChunks)
+	n.previousEligibilityTracesByGameID = make(map[uint32][]float32, maxConcurrentGames)
+	n.version = atomic.AddUint64(&latestWeightsVersion, 1)
+}
+
+// Version returns the version of the network's weights, which changes every time they do.
+func (n *Network) Version() uint64 {
+	n.weightsMu.RLock()
+	defer n.weightsMu.RUnlock()
+	return n.version
+}
+
+func (n *Network) Architecture() Architecture {
+	n.weightsMu.RLock()
+	defer n.weightsMu.RUnlock()
+	return n.arch
 }
 
-func Save(w io.Writer, gamesPlayedSoFar uint64, waitForWrites bool) error {
-	my_learningRate, my_eligibilityDecayRate := LearningParams()
+func (n *Network) Save(w io.Writer, gamesPlayedSoFar uint64, waitForWrites bool) error {
+	my_learningRate, my_eligibilityDecayRate := n.LearningParams()
 	cfg := netConfig{
 		GamesPlayedSoFar:     gamesPlayedSoFar,
 		EligibilityDecayRate: my_eligibilityDecayRate,
@@ -85,19 +133,13 @@ func Save(w io.Writer, gamesPlayedSoFar uint64, waitForWrites bool) error {
 	}
 
 	if waitForWrites {
-		fh2outWeightsMu.Lock()
-		cfg.Fh2OutWeights = fh2outWeights
-		fh2outWeightsMu.Unlock()
-		in2fhWeightsMu.Lock()
-		cfg.In2FhWeights = *in2fhWeightsPtr
-		in2fhWeightsMu.Unlock()
+		n.weightsMu.Lock()
+		cfg.Architecture, cfg.Weights = &n.arch, append([]float32{}, n.weights...)
+		n.weightsMu.Unlock()
 	} else {
-		fh2outWeightsMu.RLock()
-		cfg.Fh2OutWeights = fh2outWeights
-		fh2outWeightsMu.RUnlock()
-		in2fhWeightsMu.RLock()
-		cfg.In2FhWeights = *in2fhWeightsPtr
-		in2fhWeightsMu.RUnlock()
+		n.weightsMu.RLock()
+		cfg.Architecture, cfg.Weights = &n.arch, append([]float32{}, n.weights...)
+		n.weightsMu.RUnlock()
 	}
 
 	enc := json.NewEncoder(w)
@@ -107,7 +149,9 @@ func Save(w io.Writer, gamesPlayedSoFar uint64, waitForWrites bool) error {
 	return nil
 }
 
-func Load(r io.Reader) (uint64, error) {
+// Load replaces the network's architecture, weights and learning params with the ones that Save wrote, and returns how many games
+// it had been trained on. Files from before the architecture was configurable get converted to the default architecture.
+func (n *Network) Load(r io.Reader) (uint64, error) {
 	text, err := ioutil.ReadAll(r)
 	if err != nil {
 		return 0, fmt.Errorf("ioutil.ReadAll(r) error: %v", err)
@@ -118,146 +162,283 @@ func Load(r io.Reader) (uint64, error) {
 		return 0, fmt.Errorf("json.Unmarshal error: %v", err)
 	}
 
-	configMu.Lock()
-	learningRate = cfg.LearningRate
-	eligibilityDecayRate = cfg.EligibilityDecayRate
-	configMu.Unlock()
+	var arch Architecture
+	var weights []float32
+	if cfg.Architecture == nil {
+		if arch, weights, err = convertLegacyWeights(cfg.In2FhWeights, cfg.Fh2OutWeights); err != nil {
+			return 0, err
+		}
+	} else {
+		arch, weights = *cfg.Architecture, cfg.Weights
+		if err := arch.Validate(); err != nil {
+			return 0, fmt.Errorf("serialized network has an invalid architecture: %v", err)
+		}
+		if _, numWeights := arch.layers(); len(weights) != numWeights {
+			return 0, fmt.Errorf("serialized network has %d weights, but its architecture %q needs %d", len(weights), arch, numWeights)
+		}
+	}
+
+	n.configMu.Lock()
+	n.learningRate = cfg.LearningRate
+	n.eligibilityDecayRate = cfg.EligibilityDecayRate
+	n.configMu.Unlock()
 
-	if len(cfg.In2FhWeights) != numIn2FhConnections {
-		return 0, fmt.Errorf("serialized network In2FH weights do not match dimensions of the one in this program. expected both to have length of %d", numIn2FhConnections)
+	n.weightsMu.Lock()
+	n.setWeights(arch, weights)
+	n.weightsMu.Unlock()
+
+	return cfg.GamesPlayedSoFar, nil
+}
+
+// convertLegacyWeights converts the weights of the network that bgo used to have into the legacy architecture. Its 1 hidden layer
+// had a bias node whose output was always tanh(bias), so the output's bias weight gets scaled to make up for the plain bias.
+func convertLegacyWeights(in2fhWeights, fh2outWeights []float32) (Architecture, []float32, error) {
+	arch := legacyArchitecture()
+	layers, numWeights := arch.layers()
+	hidden, output := layers[0], layers[1]
+	if want := hidden.numOut * (hidden.numIn + 1); len(in2fhWeights) != want {
+		return arch, nil, fmt.Errorf("serialized network In2FH weights do not match dimensions of the one in this program. expected both to have length of %d", want)
 	}
-	if len(cfg.Fh2OutWeights) != numFh2OutConnections {
-		return 0, fmt.Errorf("serialized network FH2Out weights do not match dimensions of the one in this program. expected both to have length of %d", numFh2OutConnections)
+	if want := output.numOut * (output.numIn + 1); len(fh2outWeights) != want {
+		return arch, nil, fmt.Errorf("serialized network FH2Out weights do not match dimensions of the one in this program. expected both to have length of %d", want)
 	}
 
-	in2fhWeightsMu.Lock()
-	for i, v := range cfg.In2FhWeights {
-		(*in2fhWeightsPtr)[i] = v
+	weights := make([]float32, 0, numWeights)
+	weights = append(weights, in2fhWeights...)
+	weights = append(weights, fh2outWeights...)
+	weights[numWeights-1] *= tanh(bias) / bias
+	return arch, weights, nil
+}
+
+// LoadFile creates a network from a file that Save wrote, and returns how many games it had been trained on.
+func LoadFile(filePath string) (*Network, uint64, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("could not open neural network file %q: %v", filePath, err)
 	}
-	in2fhWeightsMu.Unlock()
+	defer f.Close()
 
-	fh2outWeightsMu.Lock()
-	for i, v := range cfg.Fh2OutWeights {
-		fh2outWeights[i] = v
+	n := New()
+	gamesPlayed, err := n.Load(f)
+	if err != nil {
+		return nil, 0, fmt.Errorf("could not deserialize neural network file %q: %v", filePath, err)
 	}
-	fh2outWeightsMu.Unlock()
+	return n, gamesPlayed, nil
+}
 
-	return cfg.GamesPlayedSoFar, nil
+// Clone creates a network with the same architecture, weights and learning params, but no eligibility traces.
+func (n *Network) Clone() *Network {
+	var buf bytes.Buffer
+	if err := n.Save(&buf, 0, true); err != nil {
+		panic(fmt.Sprintf("could not save the network to clone it: %v", err))
+	}
+	clone := New()
+	if _, err := clone.Load(&buf); err != nil {
+		panic(fmt.Sprintf("could not load the network to clone it: %v", err))
+	}
+	return clone
 }
 
-func RemoveUselessGameData(gameID uint32) {
-	in2fhWeightsMu.Lock()
-	go recycleBigassArray(in2fhWeightsPreviousEligibilityTracesByGameID[gameID])
-	delete(in2fhWeightsPreviousEligibilityTracesByGameID, gameID)
-	in2fhWeightsMu.Unlock()
+// RemoveUselessGameData frees the eligibility traces of a game that's over.
+func (n *Network) RemoveUselessGameData(gameID uint32) {
+	n.weightsMu.Lock()
+	traces, ok := n.previousEligibilityTracesByGameID[gameID] // Games that nobody learned from have no traces.
+	delete(n.previousEligibilityTracesByGameID, gameID)
+	n.weightsMu.Unlock()
 
-	fh2outWeightsMu.Lock()
-	delete(fh2outWeightsPreviousEligibilityTracesByGameID, gameID)
-	fh2outWeightsMu.Unlock()
+	if ok {
+		n.recycle(traces)
+	}
+}
+
+// Evaluate estimates the probabilities of each outcome for the player who's about to roll in `st`. They're always consistent.
+// A network with the equity head can only approximate them: see probabilitiesFromEquity.
+func (n *Network) Evaluate(st state.State) Probabilities {
+	probs, _, _ := n.estimate(&st)
+	return probs
 }
 
-func ValueEstimate(st state.State) (float32, [numFhNodes]float32) {
-	var estimate float32
-	var fhNodePostVals [numFhNodes]float32
-
-	in2fhWeightsMu.RLock()
-	my_in2fhWeights := *in2fhWeightsPtr
-	in2fhWeightsMu.RUnlock()
-
-	fh2outWeightsMu.RLock()
-	my_fh2outWeights := fh2outWeights
-	fh2outWeightsMu.RUnlock()
-
-	var fhNodeIdx, in2fhWeightIndex int
-	for ; fhNodeIdx < numFhNodes-1; fhNodeIdx++ {
-		// Important note: this loop only iterates over the first N-1 FH nodes-- the Nth one needs to be the new bias node!
-		var fhNodeSum float32
-		for _, num := range st {
-			fhNodeSum += num * my_in2fhWeights[in2fhWeightIndex]
-			in2fhWeightIndex++
+// ValueEstimate estimates the cubeless equity of the player who's about to roll in `st`.
+func (n *Network) ValueEstimate(st state.State) float32 {
+	_, equity, _ := n.estimate(&st)
+	return equity
+}
+
+// estimate returns both what Evaluate and what ValueEstimate return, along with the version of the weights that estimated them.
+func (n *Network) estimate(st *state.State) (Probabilities, float32, uint64) {
+	n.weightsMu.RLock()
+	out, version := n.outputs(st), n.version
+	n.weightsMu.RUnlock()
+	if len(out) == 1 {
+		return probabilitiesFromEquity(out[0]), out[0], version // Exactly what a network with the equity head estimates, even beyond +/-3.
+	}
+	var p Probabilities
+	copy(p[:], out)
+	p = p.consistent()
+	return p, p.Equity(), version
+}
+
+// outputs returns the outputs of the last layer. The caller must hold weightsMu.
+func (n *Network) outputs(st *state.State) []float32 {
+	activations := n.forward(st)
+	return activations[len(activations)-1]
+}
+
+// numOutputs is 1 for the equity head, or NumOutputs. The caller must hold weightsMu.
+func (n *Network) numOutputs() int { return n.layers[len(n.layers)-1].numOut }
+
+// forward returns the outputs of every layer, starting with the inputs themselves. The caller must hold weightsMu.
+func (n *Network) forward(st *state.State) [][]float32 {
+	outputs := make([][]float32, len(n.layers)+1)
+	outputs[0] = st[:]
+	for li, l := range n.layers {
+		in, out := outputs[li], make([]float32, l.numOut)
+		for j := range out {
+			row := n.weights[l.offset+j*(l.numIn+1) : l.offset+(j+1)*(l.numIn+1)]
+			var sum float32
+			for i, x := range in {
+				sum += x * row[i]
+			}
+			sum += bias * row[l.numIn] // Here we artificially add a bias to the layer's inputs.
+			out[j] = l.act.f(sum)
 		}
-		// Here we artificially add a bias to the input "state":
-		fhNodeSum += bias * my_in2fhWeights[in2fhWeightIndex]
-		in2fhWeightIndex++
+		outputs[li+1] = out
+	}
+	return outputs
+}
 
-		fhNodePostVal := tanh(fhNodeSum)
-		fhNodePostVals[fhNodeIdx] = fhNodePostVal
-		estimate += fhNodePostVal * my_fh2outWeights[fhNodeIdx]
+// gradient writes the derivative of output #`k` with respect to every weight into `grad`, given the outputs of `forward`.
+// `grad` must be all zeroes, since the weights that don't affect the output get skipped. The caller must hold weightsMu.
+func (n *Network) gradient(outputs [][]float32, k int, grad []float32) {
+	last := len(n.layers) - 1
+	deltas := make([]float32, n.layers[last].numOut) // The derivatives of the output with respect to the current layer's sums.
+	deltas[k] = n.layers[last].act.derivative(outputs[last+1][k])
+
+	for li := last; li >= 0; li-- {
+		l, in := n.layers[li], outputs[li]
+		var prevDeltas []float32
+		if li > 0 {
+			prevDeltas = make([]float32, l.numIn)
+		}
+		for j, delta := range deltas {
+			if delta == 0 {
+				continue
+			}
+			start, end := l.offset+j*(l.numIn+1), l.offset+(j+1)*(l.numIn+1)
+			row, rowGrad := n.weights[start:end], grad[start:end]
+			for i, x := range in {
+				rowGrad[i] = delta * x
+				if prevDeltas != nil {
+					prevDeltas[i] += delta * row[i]
+				}
+			}
+			rowGrad[l.numIn] = delta * bias
+		}
+		if prevDeltas != nil {
+			for i, out := range in {
+				prevDeltas[i] *= n.layers[li-1].act.derivative(out)
+			}
+		}
+		deltas = prevDeltas
 	}
-	// Now we artificially add a bias node to the FH layer:
-	fhNodeSum := bias
-	fhNodePostVal := tanh(fhNodeSum)
-	fhNodePostVals[fhNodeIdx] = fhNodePostVal
-	estimate += fhNodePostVal * my_fh2outWeights[fhNodeIdx]
+}
 
-	return estimate, fhNodePostVals
+func (n *Network) MultiplyLearningRate(rateMultiplier float32) {
+	n.configMu.Lock()
+	defer n.configMu.Unlock()
+	n.learningRate *= rateMultiplier
 }
 
-func MultiplyLearningRate(rateMultiplier float32) {
-	configMu.Lock()
-	defer configMu.Unlock()
-	learningRate *= rateMultiplier
+// SetLearningParams overrides the learning params, e.g. the ones that were loaded with the weights.
+func (n *Network) SetLearningParams(newLearningRate, newEligibilityDecayRate float32) {
+	n.configMu.Lock()
+	defer n.configMu.Unlock()
+	n.learningRate, n.eligibilityDecayRate = newLearningRate, newEligibilityDecayRate
 }
 
-func LearningParams() (float32, float32) {
-	configMu.RLock()
-	defer configMu.RUnlock()
-	return learningRate, eligibilityDecayRate
+func (n *Network) LearningParams() (float32, float32) {
+	n.configMu.RLock()
+	defer n.configMu.RUnlock()
+	return n.learningRate, n.eligibilityDecayRate
 }
 
-// TrainWeights back-propagates the error of an estimate against a target.
-func TrainWeights(gameID uint32, st state.State, target float32) float32 {
-	est, fh2outWeightsGradient, in2fhWeightsGradientPtr := weightGradients(st, target)
-	valueEstimateDiff := target - est // If this diff is positive, we need to add the gradient in the positive direction. else in the negative direction.
-	my_learningRate, my_eligibilityDecayRate := LearningParams()
-
-	defer in2fhWeightsMu.Unlock()
-	in2fhWeightsMu.Lock()
-	defer fh2outWeightsMu.Unlock()
-	fh2outWeightsMu.Lock()
-	// Important: don't write to any of the global vars until these locks are acquired-- that's why very little processing could happen above this line.
-
-	if _, ok := in2fhWeightsPreviousEligibilityTracesByGameID[gameID]; !ok {
-		select {
-		case in2fhWeightsPreviousEligibilityTracesByGameID[gameID] = <-bigassArrays:
-		default:
-			in2fhWeightsPreviousEligibilityTracesByGameID[gameID] = &([numIn2FhConnections]float32{})
-		}
+// TrainWeights back-propagates the error of an estimate against a target, and returns the sum of the outputs' variances.
+// A network with the equity head learns the target's equity.
+func (n *Network) TrainWeights(gameID uint32, st state.State, target Probabilities) float32 {
+	n.weightsMu.RLock()
+	outputs := n.forward(&st)
+	numOut, numWeights := n.numOutputs(), len(n.weights)
+	gradient := n.zeroedArray()
+	for k := 0; k < numOut; k++ {
+		n.gradient(outputs, k, gradient[k*numWeights:(k+1)*numWeights])
+	}
+	n.weightsMu.RUnlock()
+
+	targets := target[:]
+	if numOut == 1 {
+		targets = []float32{target.Equity()}
+	}
+	var variance float32
+	valueEstimateDiffs := make([]float32, numOut)
+	for k, est := range outputs[len(outputs)-1] {
+		valueEstimateDiffs[k] = targets[k] - est // If this diff is positive, we need to add the gradient in the positive direction. else in the negative direction.
+		variance += valueEstimateDiffs[k] * valueEstimateDiffs[k]
+	}
+	my_learningRate, my_eligibilityDecayRate := n.LearningParams()
+
+	defer n.weightsMu.Unlock()
+	n.weightsMu.Lock()
+	// Important: don't write to any of the network's fields until this lock is acquired.
+	if len(gradient) != n.numOutputs()*len(n.weights) {
+		return variance // The network was replaced by Load in the meantime.
+	}
+
+	previousEligibilityTraces, ok := n.previousEligibilityTracesByGameID[gameID]
+	if !ok {
+		previousEligibilityTraces = n.zeroedArray()
+		n.previousEligibilityTracesByGameID[gameID] = previousEligibilityTraces
 	}
-	in2fhWeightsPreviousEligibilityTraces := in2fhWeightsPreviousEligibilityTracesByGameID[gameID]
 
 	startIdx := 0
 	var wg sync.WaitGroup
-	for _, sz := range in2fhWeightsChunked { // using this global var is OK because everything is locked right now.
+	for _, sz := range n.weightChunks {
 		wg.Add(1)
 		go func(start, end int) {
-			for i := start; i <= end; i++ {
-				in2fhWeightDerivative := (*in2fhWeightsGradientPtr)[i]
-				previousEligibilityTrace := (*in2fhWeightsPreviousEligibilityTraces)[i]
-				eligibilityTrace := in2fhWeightDerivative + (my_eligibilityDecayRate * previousEligibilityTrace)
-				(*in2fhWeightsPreviousEligibilityTraces)[i] = eligibilityTrace
-				(*in2fhWeightsPtr)[i] += my_learningRate * valueEstimateDiff * eligibilityTrace
+			for i := start; i < end; i++ {
+				var change float32
+				for k, valueEstimateDiff := range valueEstimateDiffs {
+					ki := k*numWeights + i
+					eligibilityTrace := gradient[ki] + (my_eligibilityDecayRate * previousEligibilityTraces[ki])
+					previousEligibilityTraces[ki] = eligibilityTrace
+					change += valueEstimateDiff * eligibilityTrace
+				}
+				n.weights[i] += my_learningRate * change
 			}
 			wg.Done()
-		}(startIdx, startIdx+sz-1)
+		}(startIdx, startIdx+sz)
 		startIdx += sz
 	}
+	wg.Wait()
+	n.version = atomic.AddUint64(&latestWeightsVersion, 1)
+	go n.recycle(gradient)
 
-	if _, ok := fh2outWeightsPreviousEligibilityTracesByGameID[gameID]; !ok {
-		fh2outWeightsPreviousEligibilityTracesByGameID[gameID] = &([numFh2OutConnections]float32{})
-	}
-	fh2outWeightsPreviousEligibilityTraces := fh2outWeightsPreviousEligibilityTracesByGameID[gameID]
-	for i, fh2outWeightDerivative := range fh2outWeightsGradient {
-		previousEligibilityTrace := (*fh2outWeightsPreviousEligibilityTraces)[i]
-		eligibilityTrace := fh2outWeightDerivative + (my_eligibilityDecayRate * previousEligibilityTrace)
-		(*fh2outWeightsPreviousEligibilityTraces)[i] = eligibilityTrace
-		fh2outWeights[i] += my_learningRate * valueEstimateDiff * eligibilityTrace
-	}
+	return variance // The variance before adjusting the weights.
+}
 
-	wg.Wait()
-	go recycleBigassArray(in2fhWeightsGradientPtr)
+// zeroedArray returns a slice of zeroes with 1 element per output and weight. The caller must hold weightsMu.
+func (n *Network) zeroedArray() []float32 {
+	sz := n.numOutputs() * len(n.weights)
+	if arr, ok := n.zeroedArrays.Get().([]float32); ok && len(arr) == sz {
+		return arr
+	}
+	return make([]float32, sz)
+}
 
-	return valueEstimateDiff * valueEstimateDiff // The variance before adjusting the weights.
+func (n *Network) recycle(arr []float32) {
+	for i := range arr {
+		arr[i] = 0.0 // Must be all zeroed out for reuse.
+	}
+	n.zeroedArrays.Put(arr)
 }
 
 // splitIntoStartEndIndices spits out ideal chunk sizes that would most evenly split an array into N (ideally) equally-sized chunks.
@@ -299,59 +480,3 @@ func splitIntoChunkSizes(arrLen, maxChunks int) []int {
 	out = append(out, dominantNumEls)
 	return append(out, splitIntoChunkSizes(arrLen-dominantNumEls, maxChunks-1)...)
 }
-
-func weightGradients(st state.State, target float32) (float32, [numFh2OutConnections]float32, *[numIn2FhConnections]float32) {
-	var (
-		in2fhWeightIndex        int // tracks which in2fhWeight we're analyzing.
-		fh2outWeightsGradient   [numFh2OutConnections]float32
-		in2fhWeightsGradientPtr *[numIn2FhConnections]float32
-	)
-
-	select {
-	case in2fhWeightsGradientPtr = <-bigassArrays:
-	default:
-		in2fhWeightsGradientPtr = &([numIn2FhConnections]float32{})
-	}
-	in2fhWeightsGradient := *in2fhWeightsGradientPtr
-
-	est, fhNodePostVals := ValueEstimate(st)
-
-	fh2outWeightsMu.RLock()
-	my_fh2outWeights := fh2outWeights
-	fh2outWeightsMu.RUnlock()
-
-	for fhNodeIdx := 0; fhNodeIdx < numFhNodes; fhNodeIdx++ {
-		dEstimate_wrt_fh2outWeight := fhNodePostVals[fhNodeIdx] // derive this wrt weight1: `(fhNodePostVal1*weight1 + fhNodePostVal2*weight2 + ...)`.
-		fh2outWeightsGradient[fhNodeIdx] = dEstimate_wrt_fh2outWeight
-		if fhNodeIdx == numFhNodes-1 {
-			break // The final FH node is a standalone bias node with no weights coming into it-- that's why we skip those in2fh weights for the final FH node.
-		}
-		// From here down, we're talking about the incoming in2fh weights that connect to this FH node.
-
-		dEstimate_wrt_fhNodePostVal := my_fh2outWeights[fhNodeIdx]                                   // derive this wrt fhPostVal1: `estimate = (w1*fhPostVal1 + w2*fhPostVal2 + ...)`.
-		dFhNodePostVal_wrt_fhNodePreVal := 1 - dEstimate_wrt_fh2outWeight*dEstimate_wrt_fh2outWeight // derivative of tanh(x) is: 1 - tanh(x)*tanh(x)
-		for _, dFhNodePreVal_wrt_in2fhWeight := range st {                                           // derive ths wrt w1: est = (w1*inp1 + w2*inp2 + ...)
-			dEstimate_wrt_in2fhWeight := dEstimate_wrt_fhNodePostVal * dFhNodePostVal_wrt_fhNodePreVal * dFhNodePreVal_wrt_in2fhWeight
-			in2fhWeightsGradient[in2fhWeightIndex] = dEstimate_wrt_in2fhWeight
-			in2fhWeightIndex++
-		}
-		// and 1 more for the bias one.
-		dFhNodePreVal_wrt_in2fhWeight := bias
-		dEstimate_wrt_in2fhWeight := dEstimate_wrt_fhNodePostVal * dFhNodePostVal_wrt_fhNodePreVal * dFhNodePreVal_wrt_in2fhWeight
-		in2fhWeightsGradient[in2fhWeightIndex] = dEstimate_wrt_in2fhWeight
-		in2fhWeightIndex++
-	}
-
-	return est, fh2outWeightsGradient, in2fhWeightsGradientPtr
-}
-
-func sigmoid(x float32) float32 { return float32(1.0 / (1.0 + math.Exp(float64(-x)))) }
-func tanh(x float32) float32    { return float32(math.Tanh(float64(x))) }
-
-func recycleBigassArray(arrPtr *[numIn2FhConnections]float32) {
-	arr := *arrPtr
-	for i := range arr {
-		arr[i] = 0.0 // Must be all zeroed out for reuse.
-	}
-	bigassArrays <- arrPtr
-}
@@ -0,0 +1,45 @@
+package nnet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/state"
+)
+
+func TestNetworksAreIndependent(t *testing.T) {
+	b := &game.Board{}
+	b.SetUp()
+	st := state.DetectState(plyr.PCC, b)
+
+	trained, frozen := New(), New()
+	var saved bytes.Buffer
+	if err := frozen.Save(&saved, 7, true); err != nil {
+		t.Fatalf("unexpected error saving: %v", err)
+	}
+	frozenBefore := frozen.ValueEstimate(st)
+
+	trained.SetLearningParams(0.01, 0.5)
+	before := trained.ValueEstimate(st)
+	trained.TrainWeights(1, st, WonWith(game.WinKindBackgammon))
+	trained.RemoveUselessGameData(1)
+	if after := trained.ValueEstimate(st); after <= before {
+		t.Errorf("expected training towards a higher target to raise the estimate from %v, but got %v", before, after)
+	}
+	if frozenAfter := frozen.ValueEstimate(st); frozenAfter != frozenBefore {
+		t.Errorf("expected training one network to leave another alone, but its estimate went from %v to %v", frozenBefore, frozenAfter)
+	}
+	if lr, decay := frozen.LearningParams(); lr != defaultLearningRate || decay != defaultEligibilityDecayRate {
+		t.Errorf("expected the default learning params, but got %v and %v", lr, decay)
+	}
+
+	gamesPlayed, err := trained.Load(&saved)
+	if err != nil {
+		t.Fatalf("unexpected error loading: %v", err)
+	}
+	if loaded := trained.ValueEstimate(st); gamesPlayed != 7 || loaded != frozenBefore {
+		t.Errorf("expected loading to restore the saved weights and game count, but got an estimate of %v after %d games", loaded, gamesPlayed)
+	}
+}
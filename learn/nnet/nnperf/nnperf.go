@@ -65,3 +65,14 @@ func appendTotalVariance(tv float32) {
 	gameTotalVariances.data = append(gameTotalVariances.data, tv)
 	gameTotalVariances.Unlock()
 }
+
+// Restore replaces the stored performance info, e.g. with the info of a checkpoint that's being resumed from.
+func Restore(averageVariances, totalVariances []float32) {
+	gameAverageVariances.Lock()
+	gameAverageVariances.data = append([]float32{}, averageVariances...)
+	gameAverageVariances.Unlock()
+
+	gameTotalVariances.Lock()
+	gameTotalVariances.data = append([]float32{}, totalVariances...)
+	gameTotalVariances.Unlock()
+}
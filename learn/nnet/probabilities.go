@@ -0,0 +1,97 @@
+package nnet
+
+import (
+	"fmt"
+
+	"github.com/seriesoftubes/bgo/game"
+)
+
+// The outputs of a network with the probabilities head, from the POV of the player who's about to roll.
+// Like in gnubg, the gammon probabilities include the backgammons.
+const (
+	OutputWin = iota
+	OutputWinGammon
+	OutputWinBackgammon
+	OutputLoseGammon
+	OutputLoseBackgammon
+	NumOutputs
+)
+
+// Probabilities are the chances of each outcome of a game, from the POV of one player.
+type Probabilities [NumOutputs]float32
+
+// WonWith returns the probabilities of a game that's already been won by `wk`.
+func WonWith(wk game.WinKind) Probabilities {
+	var p Probabilities
+	p[OutputWin] = 1
+	if wk >= game.WinKindGammon {
+		p[OutputWinGammon] = 1
+	}
+	if wk >= game.WinKindBackgammon {
+		p[OutputWinBackgammon] = 1
+	}
+	return p
+}
+
+// Equity is the cubeless equity: the number of points that the player can expect to win.
+func (p Probabilities) Equity() float32 {
+	return 2*p[OutputWin] - 1 + p[OutputWinGammon] - p[OutputLoseGammon] + p[OutputWinBackgammon] - p[OutputLoseBackgammon]
+}
+
+// Outcomes splits the probabilities into the chances of winning and of losing exactly a single game, a gammon and a backgammon.
+func (p Probabilities) Outcomes() (win, lose [3]float32) {
+	win = [3]float32{p[OutputWin] - p[OutputWinGammon], p[OutputWinGammon] - p[OutputWinBackgammon], p[OutputWinBackgammon]}
+	lose = [3]float32{1 - p[OutputWin] - p[OutputLoseGammon], p[OutputLoseGammon] - p[OutputLoseBackgammon], p[OutputLoseBackgammon]}
+	return win, lose
+}
+
+// Flip returns the same probabilities from the POV of the other player.
+func (p Probabilities) Flip() Probabilities {
+	return Probabilities{
+		OutputWin:            1 - p[OutputWin],
+		OutputWinGammon:      p[OutputLoseGammon],
+		OutputWinBackgammon:  p[OutputLoseBackgammon],
+		OutputLoseGammon:     p[OutputWinGammon],
+		OutputLoseBackgammon: p[OutputWinBackgammon],
+	}
+}
+
+func (p Probabilities) String() string {
+	return fmt.Sprintf("win %.1f%% (gammon %.1f%%, backgammon %.1f%%), lose %.1f%% (gammon %.1f%%, backgammon %.1f%%)",
+		100*p[OutputWin], 100*p[OutputWinGammon], 100*p[OutputWinBackgammon],
+		100*(1-p[OutputWin]), 100*p[OutputLoseGammon], 100*p[OutputLoseBackgammon])
+}
+
+// consistent caps the gammons at the wins and the backgammons at the gammons, since a network's raw outputs can break those rules.
+func (p Probabilities) consistent() Probabilities {
+	min := func(a, b float32) float32 {
+		if a < b {
+			return a
+		}
+		return b
+	}
+	p[OutputWinGammon] = min(p[OutputWinGammon], p[OutputWin])
+	p[OutputWinBackgammon] = min(p[OutputWinBackgammon], p[OutputWinGammon])
+	p[OutputLoseGammon] = min(p[OutputLoseGammon], 1-p[OutputWin])
+	p[OutputLoseBackgammon] = min(p[OutputLoseBackgammon], p[OutputLoseGammon])
+	return p
+}
+
+// probabilitiesFromEquity approximates the probabilities of a network with the equity head, which only estimates the equity.
+// Equity beyond +/-1 is assumed to come from gammons, and beyond +/-2 from backgammons, so that the equity stays the same.
+func probabilitiesFromEquity(equity float32) Probabilities {
+	clamp := func(x float32) float32 {
+		if x < 0 {
+			return 0
+		} else if x > 1 {
+			return 1
+		}
+		return x
+	}
+
+	var p Probabilities
+	p[OutputWin] = clamp((equity + 1) / 2)
+	p[OutputWinGammon], p[OutputWinBackgammon] = clamp(equity-1), clamp(equity-2)
+	p[OutputLoseGammon], p[OutputLoseBackgammon] = clamp(-equity-1), clamp(-equity-2)
+	return p
+}
@@ -0,0 +1,40 @@
+package nnet
+
+import (
+	"testing"
+
+	"github.com/seriesoftubes/bgo/game"
+)
+
+func TestProbabilities(t *testing.T) {
+	for wk, want := range map[game.WinKind]float32{game.WinKindSingleGame: 1, game.WinKindGammon: 2, game.WinKindBackgammon: 3} {
+		if got := WonWith(wk).Equity(); got != want {
+			t.Errorf("expected winning a %v to be worth %v but got %v", wk, want, got)
+		}
+		if got := WonWith(wk).Flip().Equity(); got != -want {
+			t.Errorf("expected losing a %v to be worth %v but got %v", wk, -want, got)
+		}
+	}
+
+	p := Probabilities{0.6, 0.2, 0.05, 0.1, 0.01}
+	if got, want := p.Flip().Equity(), -p.Equity(); got != want {
+		t.Errorf("expected flipping to negate the equity %v, but got %v", want, got)
+	}
+	if p.Flip().Flip() != p {
+		t.Errorf("expected flipping twice to change nothing, but got %v", p.Flip().Flip())
+	}
+	if p.consistent() != p {
+		t.Errorf("expected consistent probabilities to stay the same, but got %v", p.consistent())
+	}
+	if got, want := (Probabilities{0.3, 0.5, 0.6, 0.9, 0.2}).consistent(), (Probabilities{0.3, 0.3, 0.3, 0.7, 0.2}); got != want {
+		t.Errorf("expected %v but got %v", want, got)
+	}
+}
+
+func TestProbabilitiesFromEquity(t *testing.T) {
+	for _, equity := range []float32{-3, -2.5, -1, -0.25, 0, 0.5, 1.5, 3} {
+		if got := probabilitiesFromEquity(equity).Equity(); got != equity {
+			t.Errorf("expected the approximate probabilities to keep the equity %v, but got %v", equity, got)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package nnet
+
+import (
+	"os"
+	"strings"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/state"
+)
+
+// A Set routes every position to the network of its class. Classes can share a network: unless a class gets its own network,
+// it shares the contact network. The networks' evaluations go through a cache, which forgets them whenever the weights change.
+// It's safe to use from multiple goroutines, once its networks are set.
+type Set struct {
+	nets  [state.NumClasses]*Network
+	exact ExactEvaluator
+	cache *Cache
+}
+
+// An ExactEvaluator knows the exact probabilities of some positions, like a bearoff database does.
+type ExactEvaluator interface {
+	// Probabilities returns the probabilities of each outcome for `p`, who's about to roll on board `b`, or false if it doesn't
+	// know them.
+	Probabilities(p plyr.Player, b *game.Board) (Probabilities, bool)
+}
+
+// NewSet creates a set where every class shares `contact`.
+func NewSet(contact *Network) *Set {
+	s := &Set{cache: NewCache(DefaultCacheSize)}
+	for c := range s.nets {
+		s.nets[c] = contact
+	}
+	return s
+}
+
+// NewUntrainedSet creates a set where every class shares 1 untrained network.
+func NewUntrainedSet() *Set { return NewSet(New()) }
+
+func (s *Set) Net(c state.Class) *Network { return s.nets[c] }
+
+// SetNet gives class `c` its own network. Replacing the contact network replaces it for the classes that share it too.
+// It's not safe to call while the set is being used.
+func (s *Set) SetNet(c state.Class, net *Network) {
+	if c == state.ClassContact {
+		old := s.nets[state.ClassContact]
+		for oc := range s.nets {
+			if s.nets[oc] == old {
+				s.nets[oc] = net
+			}
+		}
+		return
+	}
+	s.nets[c] = net
+}
+
+// SetExactEvaluator makes the set evaluate the positions that `exact` knows with it instead of the networks.
+// It's not safe to call while the set is being used.
+func (s *Set) SetExactEvaluator(exact ExactEvaluator) { s.exact = exact }
+
+func (s *Set) ExactEvaluator() ExactEvaluator { return s.exact }
+
+// SetCache makes the set cache its networks' evaluations in `c`, or not cache them at all if it's nil.
+// It's not safe to call while the set is being used.
+func (s *Set) SetCache(c *Cache) { s.cache = c }
+
+// CacheStats returns the stats of the set's cache, which are all 0 without one.
+func (s *Set) CacheStats() CacheStats {
+	if s.cache == nil {
+		return CacheStats{}
+	}
+	return s.cache.Stats()
+}
+
+// HasOwnNet returns whether class `c` has its own network, instead of sharing the contact network.
+func (s *Set) HasOwnNet(c state.Class) bool {
+	return c == state.ClassContact || s.nets[c] != s.nets[state.ClassContact]
+}
+
+// Networks returns each of the set's networks once.
+func (s *Set) Networks() []*Network {
+	var out []*Network
+	for c, net := range s.nets {
+		if s.HasOwnNet(state.Class(c)) {
+			out = append(out, net)
+		}
+	}
+	return out
+}
+
+// Evaluate estimates the probabilities of each outcome for `p`, who's about to roll on board `b`.
+func (s *Set) Evaluate(p plyr.Player, b *game.Board) Probabilities {
+	if s.exact != nil {
+		if probs, ok := s.exact.Probabilities(p, b); ok {
+			return probs
+		}
+	}
+	probs, _ := s.estimate(p, b)
+	return probs
+}
+
+// ValueEstimate estimates the cubeless equity of `p`, who's about to roll on board `b`.
+func (s *Set) ValueEstimate(p plyr.Player, b *game.Board) float32 {
+	if s.exact != nil {
+		if probs, ok := s.exact.Probabilities(p, b); ok {
+			return probs.Equity()
+		}
+	}
+	_, equity := s.estimate(p, b)
+	return equity
+}
+
+// estimate returns what the network of board `b`'s class estimates for `p`, from the cache if it's there.
+func (s *Set) estimate(p plyr.Player, b *game.Board) (Probabilities, float32) {
+	net := s.nets[state.Classify(b)]
+	if s.cache == nil {
+		st := state.DetectState(p, b)
+		probs, equity, _ := net.estimate(&st)
+		return probs, equity
+	}
+
+	k := newCacheKey(p, b)
+	if probs, equity, ok := s.cache.get(k, net.Version()); ok {
+		return probs, equity
+	}
+	st := state.DetectState(p, b)
+	probs, equity, version := net.estimate(&st)
+	s.cache.put(k, version, probs, equity)
+	return probs, equity
+}
+
+// TrainWeights trains the network of board `b`'s class, like Network.TrainWeights does.
+func (s *Set) TrainWeights(gameID uint32, p plyr.Player, b *game.Board, target Probabilities) float32 {
+	return s.nets[state.Classify(b)].TrainWeights(gameID, state.DetectState(p, b), target)
+}
+
+func (s *Set) RemoveUselessGameData(gameID uint32) {
+	for _, net := range s.Networks() {
+		net.RemoveUselessGameData(gameID)
+	}
+}
+
+func (s *Set) MultiplyLearningRate(rateMultiplier float32) {
+	for _, net := range s.Networks() {
+		net.MultiplyLearningRate(rateMultiplier)
+	}
+}
+
+// ClassFilePath returns where the network of class `c` is saved, next to the contact network's `filePath`.
+func ClassFilePath(filePath string, c state.Class) string {
+	if c == state.ClassContact {
+		return filePath
+	}
+	return strings.TrimSuffix(filePath, ".json") + "_" + c.String() + ".json"
+}
+
+// LoadSetFiles creates a set from the contact network's file, and the files of whichever other classes have their own network.
+// It returns how many games the contact network had been trained on.
+func LoadSetFiles(filePath string) (*Set, uint64, error) {
+	contact, gamesPlayed, err := LoadFile(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s := NewSet(contact)
+	for c := state.ClassContact + 1; c < state.NumClasses; c++ {
+		classFilePath := ClassFilePath(filePath, c)
+		if _, err := os.Stat(classFilePath); os.IsNotExist(err) {
+			continue
+		}
+		net, _, err := LoadFile(classFilePath)
+		if err != nil {
+			return nil, 0, err
+		}
+		s.SetNet(c, net)
+	}
+	return s, gamesPlayed, nil
+}
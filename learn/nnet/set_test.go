@@ -0,0 +1,90 @@
+package nnet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/state"
+)
+
+func TestSetRoutesByClass(t *testing.T) {
+	contact, race := New(), New()
+	s := NewSet(contact)
+	s.SetNet(state.ClassRace, race)
+	if len(s.Networks()) != 2 || !s.HasOwnNet(state.ClassRace) || s.HasOwnNet(state.ClassBearoff) {
+		t.Fatalf("expected only the race class to have its own network besides contact, but got %v", s.nets)
+	}
+
+	b, err := game.ParsePosition("a:O2 m:X2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := s.Evaluate(plyr.PC, b), race.Evaluate(state.DetectState(plyr.PC, b)); got != want {
+		t.Errorf("expected a race to be evaluated by the race network (%v), but got %v", want, got)
+	}
+
+	replacement := New()
+	s.SetNet(state.ClassContact, replacement)
+	if s.Net(state.ClassCrashed) != replacement || s.Net(state.ClassRace) != race {
+		t.Errorf("expected replacing the contact network to only replace it for the classes that share it")
+	}
+}
+
+// raceOnly knows that the player on roll wins every race.
+type raceOnly struct{}
+
+func (raceOnly) Probabilities(p plyr.Player, b *game.Board) (Probabilities, bool) {
+	return WonWith(game.WinKindSingleGame), state.Classify(b) == state.ClassRace
+}
+
+func TestSetExactEvaluator(t *testing.T) {
+	s := NewUntrainedSet()
+	s.SetExactEvaluator(raceOnly{})
+	for pos, wantExact := range map[string]bool{"a:O2 m:X2": true, "a:X2 l:O15 s:X5 t:X4 u:X4": false} {
+		b, err := game.ParsePosition(pos)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := s.Evaluate(plyr.PC, b) == WonWith(game.WinKindSingleGame); got != wantExact {
+			t.Errorf("expected %q to be evaluated exactly: %v, but got %v", pos, wantExact, got)
+		}
+		if got := s.ValueEstimate(plyr.PC, b) == 1; got != wantExact {
+			t.Errorf("expected %q to have an exact value estimate: %v, but got %v", pos, wantExact, got)
+		}
+	}
+}
+
+func TestLoadSetFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nnet")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	filePath := filepath.Join(dir, "weights.json")
+	if got, want := ClassFilePath(filePath, state.ClassBearoff), filepath.Join(dir, "weights_bearoff.json"); got != want {
+		t.Errorf("expected the bearoff network's file to be %q but got %q", want, got)
+	}
+	for _, c := range []state.Class{state.ClassContact, state.ClassBearoff} {
+		f, err := os.Create(ClassFilePath(filePath, c))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := New().Save(f, 9, true); err != nil {
+			t.Fatalf("unexpected error saving: %v", err)
+		}
+		f.Close()
+	}
+
+	s, gamesPlayed, err := LoadSetFiles(filePath)
+	if err != nil {
+		t.Fatalf("unexpected error loading: %v", err)
+	}
+	if gamesPlayed != 9 || !s.HasOwnNet(state.ClassBearoff) || s.HasOwnNet(state.ClassRace) {
+		t.Errorf("expected only the bearoff network to be loaded besides contact after 9 games, but got %v after %d games", s.nets, gamesPlayed)
+	}
+}
@@ -0,0 +1,35 @@
+package pubeval
+
+import (
+	"testing"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/game/turn"
+	"github.com/seriesoftubes/bgo/game/turngen"
+)
+
+func TestBestTurnOpening(t *testing.T) {
+	cases := []struct {
+		p    plyr.Player
+		roll game.Roll
+		want string
+	}{
+		// Both players should make their 5 point with an opening 31.
+		{plyr.PCC, game.Roll{3, 1}, "X;q3;s1"},
+		{plyr.PC, game.Roll{3, 1}, "O;f1;h3"},
+	}
+	for _, c := range cases {
+		b := &game.Board{}
+		b.SetUp()
+
+		got := BestTurn(b, turngen.ValidTurns(b, c.roll, c.p), c.p)
+		want, err := turn.DeserializeTurn(c.want)
+		if err != nil {
+			t.Fatalf("bad test case %q: %v", c.want, err)
+		}
+		if got.Arrayify() != want.Arrayify() {
+			t.Errorf("expected %s to play %v with %v, but got %v", c.p.Symbol(), want, c.roll, got)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package learn
+
+import (
+	"fmt"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/game/turn"
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+const (
+	QualityGood MoveQuality = iota
+	QualityDoubtful
+	QualityError
+	QualityBlunder
+)
+
+var (
+	// DefaultThresholds are the equity losses at which a turn becomes doubtful, an error or a blunder, like in gnubg.
+	DefaultThresholds = Thresholds{Doubtful: 0.04, Error: 0.08, Blunder: 0.16}
+
+	qualityNames = map[MoveQuality]string{QualityGood: "good", QualityDoubtful: "doubtful", QualityError: "error", QualityBlunder: "blunder"}
+)
+
+type (
+	// MoveQuality classifies a turn by how much equity it loses compared to the best turn.
+	MoveQuality uint8
+
+	// Thresholds are the smallest equity losses that make a turn doubtful, an error or a blunder.
+	Thresholds struct {
+		Doubtful, Error, Blunder float32
+	}
+)
+
+func ParseMoveQuality(s string) (MoveQuality, error) {
+	for q, name := range qualityNames {
+		if s == name {
+			return q, nil
+		}
+	}
+	return QualityGood, fmt.Errorf("unknown move quality %q, should be one of 'good', 'doubtful', 'error' or 'blunder'", s)
+}
+
+func (q MoveQuality) String() string { return qualityNames[q] }
+
+// Classify returns the quality of a turn that loses `equityLoss` compared to the best turn.
+func (th Thresholds) Classify(equityLoss float32) MoveQuality {
+	switch {
+	case equityLoss >= th.Blunder:
+		return QualityBlunder
+	case equityLoss >= th.Error:
+		return QualityError
+	case equityLoss >= th.Doubtful:
+		return QualityDoubtful
+	}
+	return QualityGood
+}
+
+// EquityLoss returns how much equity player `p` loses by playing `t` instead of the best of `turns`, looking `ply` rolls ahead,
+// along with the best turn. The loss is never negative.
+func EquityLoss(nets *nnet.Set, b *game.Board, turns map[turn.TurnArray]turn.Turn, t turn.Turn, p plyr.Player, ply int) (float32, RankedTurn) {
+	return NewSearch(nets, ply).EquityLoss(b, turns, t, p)
+}
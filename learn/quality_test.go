@@ -0,0 +1,33 @@
+package learn
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		loss float32
+		want MoveQuality
+	}{
+		{0, QualityGood},
+		{0.039, QualityGood},
+		{0.04, QualityDoubtful},
+		{0.1, QualityError},
+		{0.16, QualityBlunder},
+		{2, QualityBlunder},
+	}
+	for _, c := range cases {
+		if got := DefaultThresholds.Classify(c.loss); got != c.want {
+			t.Errorf("expected a loss of %v to be %v but got %v", c.loss, c.want, got)
+		}
+	}
+}
+
+func TestParseMoveQuality(t *testing.T) {
+	for _, q := range []MoveQuality{QualityGood, QualityDoubtful, QualityError, QualityBlunder} {
+		if got, err := ParseMoveQuality(q.String()); err != nil || got != q {
+			t.Errorf("expected %q to parse as %v but got %v, %v", q.String(), q, got, err)
+		}
+	}
+	if _, err := ParseMoveQuality("meh"); err == nil {
+		t.Errorf("expected an error for an unknown move quality")
+	}
+}
@@ -3,13 +3,15 @@ package learn
 
 import (
 	"sync"
+	"time"
 
 	"github.com/seriesoftubes/bgo/game"
 	"github.com/seriesoftubes/bgo/game/plyr"
 	"github.com/seriesoftubes/bgo/game/turn"
-	"github.com/seriesoftubes/bgo/game/turngen"
+	"github.com/seriesoftubes/bgo/learn/cube"
 	"github.com/seriesoftubes/bgo/learn/nnet"
 	"github.com/seriesoftubes/bgo/learn/nnet/nnperf"
+	"github.com/seriesoftubes/bgo/race"
 	"github.com/seriesoftubes/bgo/random"
 	"github.com/seriesoftubes/bgo/state"
 )
@@ -46,8 +48,11 @@ var uniqueRolls [21]game.Roll = [21]game.Roll{
 }
 
 type Agent struct {
+	nets *nnet.Set // The networks that the agent evaluates positions with, and learns from its games.
 	// Epsilon = probability of choosing a random action (at least at first until annealing kicks in)
 	epsilon                         float32
+	cubeLife                        float32 // How live the agent assumes the cube to be, when it decides what to do with it.
+	match                           *Match  // The match that the agent plays in, or nil if it plays for money.
 	game                            *game.Game
 	player                          plyr.Player
 	numTrainings                    uint32
@@ -55,41 +60,8 @@ type Agent struct {
 	statsWG                         sync.WaitGroup
 }
 
-func NewAgent(epsilon float32) *Agent {
-	return &Agent{epsilon: epsilon}
-}
-
-func bestTurnOnePly(b *game.Board, bvt map[turn.TurnArray]turn.Turn, p plyr.Player) [3]*turn.Turn {
-	var topVals [3]*float32 // the top-most negative values.
-	var topTurns [3]*turn.Turn
-	updateTop := func(val float32, t turn.Turn) {
-		if valPtr0 := topVals[0]; valPtr0 == nil || val > *valPtr0 {
-			topVals[0] = &val
-			topTurns[0] = &t
-			return
-		} // From here down, val <= vals0
-
-		if valPtr1 := topVals[1]; valPtr1 == nil || val > *valPtr1 {
-			topVals[1] = &val
-			topTurns[1] = &t
-			return
-		} // From here down, val <= vals1
-
-		if valPtr2 := topVals[2]; valPtr2 == nil || val > *valPtr2 {
-			topVals[2] = &val
-			topTurns[2] = &t
-		}
-	}
-
-	enemy := p.Enemy()
-	for _, t := range bvt {
-		bcop := b.Copy()
-		bcop.MustExecuteTurn(t, false)
-		val, _ := nnet.ValueEstimate(state.DetectState(enemy, bcop))
-		updateTop(-1*val, t) // the top consists of the most negative values so multiply val by -1.
-	}
-
-	return topTurns
+func NewAgent(nets *nnet.Set, epsilon float32) *Agent {
+	return &Agent{nets: nets, epsilon: epsilon, cubeLife: cube.DefaultCubeLife}
 }
 
 func updateRollAVG(rollIdx int, total *float32, newNum float32) {
@@ -100,51 +72,16 @@ func updateRollAVG(rollIdx int, total *float32, newNum float32) {
 	}
 }
 
-func bestTurnTwoPly(b *game.Board, heroTurns0 map[turn.TurnArray]turn.Turn, hero plyr.Player) turn.Turn {
-	enemy := hero.Enemy()
-	bestVal := float32(-9e37)
-	var bestTurn turn.Turn
-
-	for _, heroTurn0 := range heroTurns0 { // these turns yield the game over to the enemy.
-		enemyBoard1 := b.Copy()
-		enemyBoard1.MustExecuteTurn(heroTurn0, false)
-
-		var totalRollEquity1 float32
-		for rollIdx1, r1 := range uniqueRolls {
-			var totalTurnVal1, numTurns1 float32
-			for _, enemyTurnPtr1 := range bestTurnOnePly(enemyBoard1, turngen.ValidTurns(enemyBoard1, r1, enemy), enemy) {
-				if enemyTurnPtr1 == nil {
-					break
-				}
-				heroBoard2 := enemyBoard1.Copy()
-				heroBoard2.MustExecuteTurn(*enemyTurnPtr1, false)
-				val, _ := nnet.ValueEstimate(state.DetectState(hero, heroBoard2))
-				totalTurnVal1 += val
-				numTurns1++
-			}
-
-			var avgTurnVal1 float32
-			if numTurns1 > 0.0 {
-				avgTurnVal1 = totalTurnVal1 / numTurns1
-			} else {
-				// there were no turns for the enemy, so go straight to hero turn
-				val, _ := nnet.ValueEstimate(state.DetectState(hero, enemyBoard1))
-				avgTurnVal1 = val
-			}
-			if rollIdx1 < numOneOfAKindRolls {
-				totalRollEquity1 += avgTurnVal1
-			} else {
-				totalRollEquity1 += 2 * avgTurnVal1
-			}
-		}
-
-		if totalRollEquity1 > bestVal {
-			bestTurn = heroTurn0
-			bestVal = totalRollEquity1
-		}
+// ThinkingBudget splits the time left on a player's clock evenly across the number of turns that the player probably has left.
+func ThinkingBudget(b *game.Board, p plyr.Player, timeLeft time.Duration) time.Duration {
+	pipC, pipCC := b.PipCounts()
+	pips := pipC
+	if p == plyr.PCC {
+		pips = pipCC
 	}
 
-	return bestTurn
+	turnsLeft := 1 + float64(pips)/race.PipsPerRoll
+	return time.Duration(float64(timeLeft) / turnsLeft)
 }
 
 func (a *Agent) WaitForStats() { a.statsWG.Wait() }
@@ -168,7 +105,7 @@ func (a *Agent) TransmitStats() {
 // It's assumed that this is only called when the Agent's GameController is starting a new game.
 func (a *Agent) SetGame(g *game.Game) {
 	if a.game != nil {
-		go func(gid uint32) { nnet.RemoveUselessGameData(gid) }(a.game.ID)
+		go func(gid uint32) { a.nets.RemoveUselessGameData(gid) }(a.game.ID)
 	}
 
 	a.game = g
@@ -186,7 +123,75 @@ func (a *Agent) EpsilonGreedyAction(b *game.Board, validTurnsForState map[turn.T
 		panic("should have prevented this function from being called!")
 	}
 
-	return *(bestTurnOnePly(b, validTurnsForState, a.player)[0])
+	return a.BestTurn(b, validTurnsForState, 0)
+}
+
+// AcceptsResignation decides whether the agent would rather take `offer` points from `resigner` than play on.
+// The agent's equity is estimated by assuming that `resigner` plays its best turn out of `validTurns`. In a match, it compares
+// match winning chances instead.
+func (a *Agent) AcceptsResignation(b *game.Board, validTurns map[turn.TurnArray]turn.Turn, resigner plyr.Player, offer game.WinKind) bool {
+	bcop := b.Copy()
+	if ranked, _ := a.search(0).RankTurns(b, validTurns, resigner); len(ranked) > 0 {
+		bcop.MustExecuteTurn(ranked[0].Turn, false)
+	}
+	if a.match != nil {
+		cv := a.cubeValue()
+		offered := a.match.Table.MWC(a.match.Score(resigner.Enemy()).After(int(offer) * cv))
+		return offered >= a.match.MatchWinningChance(resigner.Enemy(), a.nets.Evaluate(resigner.Enemy(), bcop), cv)
+	}
+	agentEquity := a.nets.ValueEstimate(resigner.Enemy(), bcop)
+	return float32(offer) >= agentEquity
+}
+
+// EpsilonGreedyActionWithin is like EpsilonGreedyAction, but it looks as many rolls ahead as it can (up to `maxPly`) before `deadline`.
+func (a *Agent) EpsilonGreedyActionWithin(b *game.Board, validTurnsForState map[turn.TurnArray]turn.Turn, maxPly int, deadline time.Time) turn.Turn {
+	if len(validTurnsForState) == 0 {
+		panic("should have prevented this function from being called!")
+	}
+
+	if random.Float32Between(0, 1) < a.epsilon { // exploration mode.
+		for _, t := range validTurnsForState {
+			return t
+		}
+	}
+
+	ranked, _ := a.search(0).RankTurns(b, validTurnsForState, a.player)
+	for ply := 1; ply <= maxPly; ply++ {
+		s := a.search(ply)
+		s.Deadline = deadline
+		deeper, finished := s.RankTurns(b, validTurnsForState, a.player)
+		if !finished {
+			break
+		}
+		ranked = deeper
+	}
+	return ranked[0].Turn
+}
+
+// BestTurn picks the agent's best turn out of `validTurnsForState`, looking `ply` rolls ahead.
+func (a *Agent) BestTurn(b *game.Board, validTurnsForState map[turn.TurnArray]turn.Turn, ply int) turn.Turn {
+	if len(validTurnsForState) == 0 {
+		panic("should have prevented this function from being called!")
+	}
+
+	ranked, _ := a.search(ply).RankTurns(b, validTurnsForState, a.player)
+	return ranked[0].Turn
+}
+
+// SetMatch makes the agent judge its decisions by their match winning chances in `m` from now on. A nil match makes it play
+// for money.
+func (a *Agent) SetMatch(m *Match) { a.match = m }
+
+// search returns the search that the agent picks its turns with, looking `ply` rolls ahead. In a match, it ranks turns by their
+// MWC with the cube of the agent's game.
+func (a *Agent) search(ply int) Search { return NewSearch(a.nets, ply).InMatch(a.match, a.cubeValue()) }
+
+// cubeValue returns the value of the cube in the agent's game.
+func (a *Agent) cubeValue() int {
+	if a.game == nil {
+		return 1
+	}
+	return CubeValue(a.game.Cube)
 }
 
 func (a *Agent) DetectState() state.State {
@@ -203,22 +208,19 @@ func (a *Agent) LearnNonFinalState(previousBoard, currentBoard *game.Board) {
 	// `previousBoard` is the state that the hero made a move on which led to `currentBoard`.
 	// so the value of `currentBoard` from the hero's POV == -1*(currentboard_value_from_enemyPOV).
 	// a.player is the player who made the transition from previous to current board.
-	newStateFromEnemyPOV := state.DetectState(a.player.Enemy(), currentBoard)
-	previousStateHeroPOV := state.DetectState(a.player, previousBoard)
-	enemyEst, _ := nnet.ValueEstimate(newStateFromEnemyPOV)
+	// Each board trains the network of its own class, but the target can come from the network of another class.
+	enemyEst := a.nets.Evaluate(a.player.Enemy(), currentBoard)
 
-	a.totalVarianceAcrossAllTrainings += nnet.TrainWeights(a.game.ID, previousStateHeroPOV, -enemyEst)
+	a.totalVarianceAcrossAllTrainings += a.nets.TrainWeights(a.game.ID, a.player, previousBoard, enemyEst.Flip())
 	a.numTrainings++
 }
 
 func (a *Agent) LearnFinal(preWinningMoveBoard, boardInWonState *game.Board, rewardForNextState game.WinKind) {
-	actualReward := float32(rewardForNextState)
-	previousStateHeroPOV := state.DetectState(a.player, preWinningMoveBoard)
+	actualReward := nnet.WonWith(rewardForNextState)
 
-	a.totalVarianceAcrossAllTrainings += nnet.TrainWeights(a.game.ID, previousStateHeroPOV, actualReward)
+	a.totalVarianceAcrossAllTrainings += a.nets.TrainWeights(a.game.ID, a.player, preWinningMoveBoard, actualReward)
 	a.numTrainings++
 
-	losingStateEnemyPOV := state.DetectState(a.player.Enemy(), boardInWonState)
-	a.totalVarianceAcrossAllTrainings += nnet.TrainWeights(a.game.ID, losingStateEnemyPOV, -actualReward)
+	a.totalVarianceAcrossAllTrainings += a.nets.TrainWeights(a.game.ID, a.player.Enemy(), boardInWonState, actualReward.Flip())
 	a.numTrainings++
 }
@@ -0,0 +1,284 @@
+package learn
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/game/turn"
+	"github.com/seriesoftubes/bgo/game/turngen"
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+const (
+	MaxPly              = 3
+	numRollOutcomes     = float32(36)
+	numDeeperCandidates = 3 // By default, only the top 3 turns are worth looking further ahead at.
+)
+
+var (
+	// DefaultFilter only looks further ahead at the 3 best turns.
+	DefaultFilter = Filter{MaxCandidates: numDeeperCandidates}
+
+	// searchTokens limits how many extra goroutines all searches together may use, to about 1 per CPU.
+	searchTokens = make(chan struct{}, runtime.GOMAXPROCS(0))
+)
+
+// A RankedTurn is a turn along with the equity and probabilities that the player who played it has afterwards.
+type RankedTurn struct {
+	Turn          turn.Turn
+	Equity        float32 // The cubeless equity, or the match winning chance when the turn was ranked in a match.
+	Probabilities nnet.Probabilities
+	Ply           int // How many rolls ahead the search looked in order to estimate the equity.
+}
+
+// A valuer turns `p`'s probabilities of each outcome into the value that turns are ranked by. A nil valuer ranks them by
+// cubeless equity.
+type valuer func(p plyr.Player, probs nnet.Probabilities) float32
+
+func (v valuer) value(p plyr.Player, probs nnet.Probabilities) float32 {
+	if v == nil {
+		return probs.Equity()
+	}
+	return v(p, probs)
+}
+
+// A Filter picks which turns are worth looking further ahead at, out of the turns that the neural net ranked at 0 ply. The best
+// turn always is.
+type Filter struct {
+	MaxCandidates int     // How many of the best turns to keep. 0 keeps them all.
+	MaxEquityLoss float32 // How much worse than the best turn a turn may be to be kept. 0 keeps them all.
+}
+
+// numCandidates returns how many of the `ranked` turns, which are sorted from best to worst, the filter keeps.
+func (f Filter) numCandidates(ranked []RankedTurn) int {
+	n := len(ranked)
+	if f.MaxCandidates > 0 && f.MaxCandidates < n {
+		n = f.MaxCandidates
+	}
+	if f.MaxEquityLoss > 0 {
+		for i := 1; i < n; i++ {
+			if ranked[0].Equity-ranked[i].Equity > f.MaxEquityLoss {
+				return i
+			}
+		}
+	}
+	return n
+}
+
+// A Search is an expectimax search that looks `Ply` rolls ahead with `Nets`. A position that's about to be rolled is a chance
+// node, whose value is the average over the 21 distinct rolls of the roller's best turn. The roller's turns are all ranked by
+// the neural net, and only the ones that pass `Filter` get looked at further ahead. The search runs in parallel, on as many
+// goroutines as there are idle CPUs.
+type Search struct {
+	Nets   *nnet.Set
+	Ply    int
+	Filter Filter
+	// If set, the search gives up on looking further ahead once it passes, at every ply, and only the 0-ply ranking is kept.
+	Deadline time.Time
+	valuer   valuer
+}
+
+// NewSearch creates a search that looks `ply` rolls ahead with `nets`, and the default filter.
+func NewSearch(nets *nnet.Set, ply int) Search {
+	return Search{Nets: nets, Ply: ply, Filter: DefaultFilter}
+}
+
+// InMatch returns the same search, but it ranks turns by their match winning chances in match `m` with the cube at `cubeValue`.
+// A nil match ranks them by cubeless equity.
+func (s Search) InMatch(m *Match, cubeValue int) Search {
+	s.valuer = m.valuer(cubeValue)
+	return s
+}
+
+// PositionEquity estimates the cubeless equity of `p`, who is about to roll on board `b`, by looking `ply` rolls ahead with `nets`.
+func PositionEquity(nets *nnet.Set, b *game.Board, p plyr.Player, ply int) float32 {
+	return NewSearch(nets, ply).PositionProbabilities(b, p).Equity()
+}
+
+// PositionProbabilities estimates the probabilities of each outcome for `p`, who is about to roll on board `b`, by looking `ply`
+// rolls ahead with `nets`.
+func PositionProbabilities(nets *nnet.Set, b *game.Board, p plyr.Player, ply int) nnet.Probabilities {
+	return NewSearch(nets, ply).PositionProbabilities(b, p)
+}
+
+// RollLuck returns how much more equity roll `r` gives `p` than the average roll does, assuming that `p` plays the best turn
+// for each roll, looking `ply` rolls ahead after that.
+func RollLuck(nets *nnet.Set, b *game.Board, r game.Roll, p plyr.Player, ply int) float32 {
+	return NewSearch(nets, ply).RollLuck(b, r, p)
+}
+
+// RankTurns sorts `turns` from best to worst for player `p`. The `numCandidates` most promising turns get looked at `ply` rolls
+// ahead, and come first.
+func RankTurns(nets *nnet.Set, b *game.Board, turns map[turn.TurnArray]turn.Turn, p plyr.Player, ply, numCandidates int) []RankedTurn {
+	ranked, _ := NewSearch(nets, ply).rankTurns(b, turns, p, Filter{MaxCandidates: numCandidates})
+	return ranked
+}
+
+// PositionProbabilities estimates the probabilities of `p`, who is about to roll on board `b`. At 0 ply, it's the neural net's
+// estimate. At N ply, it's the average over all rolls of p's best (N-1)-ply turn.
+func (s Search) PositionProbabilities(b *game.Board, p plyr.Player) nnet.Probabilities {
+	if winner := b.Winner(); winner != 0 {
+		if winner == p {
+			return nnet.WonWith(b.WinKind())
+		}
+		return nnet.WonWith(b.WinKind()).Flip()
+	}
+
+	if s.Ply == 0 || s.pastDeadline() {
+		return s.Nets.Evaluate(p, b)
+	}
+
+	var byRoll [len(uniqueRolls)]nnet.Probabilities
+	shallower := s.shallower()
+	parallelFor(len(uniqueRolls), func(rollIdx int) {
+		byRoll[rollIdx] = shallower.rollProbabilities(b, uniqueRolls[rollIdx], p)
+	})
+
+	var total nnet.Probabilities
+	for rollIdx, probs := range byRoll {
+		for i := range total {
+			updateRollAVG(rollIdx, &total[i], probs[i])
+		}
+	}
+	for i := range total {
+		total[i] /= numRollOutcomes
+	}
+	return total
+}
+
+// RollLuck returns how much more equity (or MWC, in a match) roll `r` gives `p` than the average roll does, assuming that `p`
+// plays the best turn for each roll, looking `Ply` rolls ahead after that.
+func (s Search) RollLuck(b *game.Board, r game.Roll, p plyr.Player) float32 {
+	var equities [len(uniqueRolls)]float32
+	parallelFor(len(uniqueRolls), func(rollIdx int) {
+		equities[rollIdx] = s.valuer.value(p, s.rollProbabilities(b, uniqueRolls[rollIdx], p))
+	})
+
+	sorted := r.Sorted()
+	var total, rolled float32
+	for rollIdx, ur := range uniqueRolls {
+		if ur == sorted {
+			rolled = equities[rollIdx]
+		}
+		updateRollAVG(rollIdx, &total, equities[rollIdx])
+	}
+	return rolled - total/numRollOutcomes
+}
+
+// RankTurns sorts `turns` from best to worst for player `p`. Every turn gets a 0-ply equity, and the ones that pass the filter get
+// re-evaluated `Ply` rolls ahead, so they come first.
+// If the deadline passes before the search is done, it returns false along with the 0-ply ranking.
+func (s Search) RankTurns(b *game.Board, turns map[turn.TurnArray]turn.Turn, p plyr.Player) ([]RankedTurn, bool) {
+	return s.rankTurns(b, turns, p, s.Filter)
+}
+
+// rankTurns is like RankTurns, but `rootFilter` picks which of `turns` get looked at further ahead, instead of the search's filter.
+func (s Search) rankTurns(b *game.Board, turns map[turn.TurnArray]turn.Turn, p plyr.Player, rootFilter Filter) ([]RankedTurn, bool) {
+	ranked := make([]RankedTurn, 0, len(turns))
+	for _, t := range turns {
+		ranked = append(ranked, s.newRankedTurn(t, p, s.atPly(0).turnProbabilities(b, t, p), 0))
+	}
+	sortRankedTurns(ranked)
+
+	if s.Ply == 0 {
+		return ranked, true
+	}
+
+	numCandidates := rootFilter.numCandidates(ranked)
+	deeper := make([]RankedTurn, numCandidates)
+	parallelFor(numCandidates, func(i int) {
+		if !s.pastDeadline() {
+			deeper[i] = s.newRankedTurn(ranked[i].Turn, p, s.turnProbabilities(b, ranked[i].Turn, p), s.Ply)
+		}
+	})
+	if s.pastDeadline() { // Some of the candidates were skipped, or cut short deeper down.
+		return ranked, false
+	}
+	sortRankedTurns(deeper)
+
+	return append(deeper, ranked[numCandidates:]...), true
+}
+
+// EquityLoss returns how much equity player `p` loses by playing `t` instead of the best of `turns`, along with the best turn.
+// The loss is never negative.
+func (s Search) EquityLoss(b *game.Board, turns map[turn.TurnArray]turn.Turn, t turn.Turn, p plyr.Player) (float32, RankedTurn) {
+	ranked, _ := s.RankTurns(b, turns, p)
+	best := ranked[0]
+
+	chosen := t.Arrayify()
+	if best.Turn.Arrayify() == chosen {
+		return 0, best
+	}
+	equity := s.valuer.value(p, s.turnProbabilities(b, t, p))
+	if equity >= best.Equity {
+		return 0, best
+	}
+	return best.Equity - equity, best
+}
+
+// rollProbabilities estimates the probabilities of `p` after playing their best turn for roll `r`, looking `Ply` rolls ahead
+// after that.
+func (s Search) rollProbabilities(b *game.Board, r game.Roll, p plyr.Player) nnet.Probabilities {
+	if ranked, _ := s.RankTurns(b, turngen.ValidTurns(b, r, p), p); len(ranked) > 0 {
+		return ranked[0].Probabilities
+	}
+	return s.PositionProbabilities(b, p.Enemy()).Flip() // There are no valid turns for this roll.
+}
+
+// turnProbabilities estimates `p`'s probabilities after playing `t`, looking `Ply` rolls ahead.
+func (s Search) turnProbabilities(b *game.Board, t turn.Turn, p plyr.Player) nnet.Probabilities {
+	bcop := b.Copy()
+	bcop.MustExecuteTurn(t, false)
+	return s.PositionProbabilities(bcop, p.Enemy()).Flip()
+}
+
+func (s Search) newRankedTurn(t turn.Turn, p plyr.Player, probs nnet.Probabilities, ply int) RankedTurn {
+	return RankedTurn{Turn: t, Equity: s.valuer.value(p, probs), Probabilities: probs, Ply: ply}
+}
+
+// atPly returns the same search, but looking `ply` rolls ahead.
+func (s Search) atPly(ply int) Search {
+	s.Ply = ply
+	return s
+}
+
+// shallower returns the same search, but looking 1 less roll ahead, for the positions after each roll.
+func (s Search) shallower() Search {
+	s.Ply--
+	return s
+}
+
+// pastDeadline returns whether the search has a deadline, and it has passed.
+func (s Search) pastDeadline() bool {
+	return !s.Deadline.IsZero() && time.Now().After(s.Deadline)
+}
+
+func sortRankedTurns(ranked []RankedTurn) {
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Equity > ranked[j].Equity })
+}
+
+// parallelFor calls `f` with every number from 0 to n-1. The calls run on other goroutines while there are idle CPUs, and on
+// this one otherwise, so nested searches never wait for each other.
+func parallelFor(n int, f func(i int)) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		select {
+		case searchTokens <- struct{}{}:
+			wg.Add(1)
+			go func(i int) {
+				defer func() {
+					<-searchTokens
+					wg.Done()
+				}()
+				f(i)
+			}(i)
+		default:
+			f(i)
+		}
+	}
+	wg.Wait()
+}
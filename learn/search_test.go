@@ -0,0 +1,133 @@
+package learn
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/game/turngen"
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+func TestRollLuckAveragesToZero(t *testing.T) {
+	nets := nnet.NewUntrainedSet()
+	b := &game.Board{}
+	b.SetUp()
+
+	var total float32
+	for rollIdx, r := range uniqueRolls {
+		updateRollAVG(rollIdx, &total, RollLuck(nets, b, r, plyr.PCC, 0))
+	}
+	if avg := total / numRollOutcomes; math.Abs(float64(avg)) > 1e-4 {
+		t.Errorf("expected the luck of the average roll to be 0 but got %v", avg)
+	}
+
+	if RollLuck(nets, b, game.Roll{3, 1}, plyr.PCC, 0) != RollLuck(nets, b, game.Roll{1, 3}, plyr.PCC, 0) {
+		t.Errorf("expected the order of the dice not to matter")
+	}
+}
+
+func TestFilterNumCandidates(t *testing.T) {
+	ranked := []RankedTurn{{Equity: 0.5}, {Equity: 0.45}, {Equity: 0.3}, {Equity: 0.2}}
+	cases := []struct {
+		f    Filter
+		want int
+	}{
+		{Filter{}, 4},
+		{Filter{MaxCandidates: 2}, 2},
+		{Filter{MaxCandidates: 10}, 4},
+		{Filter{MaxEquityLoss: 0.1}, 2},
+		{Filter{MaxCandidates: 1, MaxEquityLoss: 0.1}, 1},
+		{Filter{MaxEquityLoss: 0.01}, 1},
+	}
+	for _, c := range cases {
+		if got := c.f.numCandidates(ranked); got != c.want {
+			t.Errorf("expected %+v to keep %d turns but got %d", c.f, c.want, got)
+		}
+	}
+}
+
+func TestSearchAveragesOverRolls(t *testing.T) {
+	nets := nnet.NewUntrainedSet()
+	b := &game.Board{}
+	b.SetUp()
+
+	var want float32
+	for rollIdx, r := range uniqueRolls {
+		var best float32 = -10
+		for _, tn := range turngen.ValidTurns(b, r, plyr.PCC) {
+			bcop := b.Copy()
+			bcop.MustExecuteTurn(tn, false)
+			if eq := nets.Evaluate(plyr.PC, bcop).Flip().Equity(); eq > best {
+				best = eq
+			}
+		}
+		updateRollAVG(rollIdx, &want, best)
+	}
+	want /= numRollOutcomes
+
+	if got := PositionEquity(nets, b, plyr.PCC, 1); math.Abs(float64(got-want)) > 1e-4 {
+		t.Errorf("expected the 1-ply equity to be the average of the best turn for each roll, %v, but got %v", want, got)
+	}
+}
+
+func TestSearchFilter(t *testing.T) {
+	nets := nnet.NewUntrainedSet()
+	b := &game.Board{}
+	b.SetUp()
+	turns := turngen.ValidTurns(b, game.Roll{6, 5}, plyr.PCC)
+
+	s := NewSearch(nets, 1)
+	s.Filter = Filter{MaxCandidates: 2}
+	ranked, finished := s.RankTurns(b, turns, plyr.PCC)
+	if !finished || len(ranked) != len(turns) {
+		t.Fatalf("expected all %d turns to be ranked, but got %d (finished: %v)", len(turns), len(ranked), finished)
+	}
+	for i, rt := range ranked {
+		wantPly := 0
+		if i < 2 {
+			wantPly = 1
+		}
+		if rt.Ply != wantPly {
+			t.Errorf("expected turn #%d to be looked at %d ply ahead, but got %d", i, wantPly, rt.Ply)
+		}
+	}
+}
+
+func TestSearchDeadline(t *testing.T) {
+	nets := nnet.NewUntrainedSet()
+	b := &game.Board{}
+	b.SetUp()
+	turns := turngen.ValidTurns(b, game.Roll{6, 5}, plyr.PCC)
+	want, _ := NewSearch(nets, 0).RankTurns(b, turns, plyr.PCC)
+
+	for _, deadline := range []time.Duration{-time.Second, 20 * time.Millisecond} {
+		s := NewSearch(nets, MaxPly)
+		s.Deadline = time.Now().Add(deadline)
+		limit := time.Second
+		if deadline > 0 {
+			limit += deadline
+		}
+		start := time.Now()
+		ranked, finished := s.RankTurns(b, turns, plyr.PCC)
+		if elapsed := time.Since(start); elapsed > limit {
+			t.Errorf("expected a %d-ply search to stop soon after its deadline in %v, but it took %v", MaxPly, deadline, elapsed)
+		}
+		if finished {
+			t.Errorf("expected a %d-ply search with a deadline in %v not to finish", MaxPly, deadline)
+		}
+		for i := range ranked {
+			if ranked[i].Ply != 0 || ranked[i].Equity != want[i].Equity {
+				t.Errorf("expected the 0-ply ranking when the deadline passes, but turn #%d is %+v", i, ranked[i])
+			}
+		}
+	}
+
+	s := NewSearch(nets, MaxPly)
+	s.Deadline = time.Now().Add(-time.Second)
+	if got, want := s.PositionProbabilities(b, plyr.PCC), nets.Evaluate(plyr.PCC, b); got != want {
+		t.Errorf("expected a search past its deadline to fall back to the 0-ply probabilities %v, but got %v", want, got)
+	}
+}
@@ -0,0 +1,146 @@
+// Package trainconf describes a training run: how many games to play, and the hyperparameters to play them with.
+package trainconf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+
+	"github.com/seriesoftubes/bgo/learn/nnet"
+	"github.com/seriesoftubes/bgo/state"
+)
+
+// A Config describes a training run. Zero learning params and a nil architecture mean "keep the ones that were saved with the weights".
+type Config struct {
+	TotalGamesToPlay uint64 // Across all goroutines.
+	Goroutines       uint64
+	Epsilon          float32 // The chance that an agent picks a random turn instead of the best one.
+
+	LearningRate         float32
+	EligibilityDecayRate float32
+	// Every `LearningRateReductionInterval` games, the learning rate gets multiplied by `LearningRateReductionMultiplier`.
+	LearningRateReductionInterval   uint64
+	LearningRateReductionMultiplier float32
+
+	// The hidden layers of the network. A network that's loaded from WeightsInFile must already have them.
+	Architecture *nnet.Architecture
+	// The position classes besides "contact" that get their own network: "crashed", "race" or "bearoff". The rest share the contact network.
+	ClassNetworks []string
+
+	WeightsInFile  string // The file that contains the initial neural net config.
+	WeightsOutFile string // The file that will contain the updated neural net config.
+
+	// A checkpoint is saved next to the out file every this many games or minutes, whichever comes first. 0 turns either off.
+	CheckpointEveryGames   uint64
+	CheckpointEveryMinutes float64
+	CheckpointsToKeep      int // Older checkpoints get deleted.
+}
+
+// Default returns the config that's used for whatever isn't set in a config file or by flags.
+func Default() *Config {
+	goroutines := uint64(runtime.NumCPU() / 2)
+	if goroutines == 0 {
+		goroutines = 1
+	}
+	return &Config{
+		TotalGamesToPlay:                7 * 60000,
+		Goroutines:                      goroutines,
+		Epsilon:                         1.0,
+		LearningRateReductionInterval:   30000,
+		LearningRateReductionMultiplier: 0.25,
+		ClassNetworks:                   []string{state.ClassCrashed.String(), state.ClassRace.String(), state.ClassBearoff.String()},
+		CheckpointEveryGames:            10000,
+		CheckpointEveryMinutes:          30,
+		CheckpointsToKeep:               3,
+	}
+}
+
+// Load reads a config from JSON on top of the defaults, and validates it. Unknown fields are an error, so that typos don't go unnoticed.
+func Load(r io.Reader) (*Config, error) {
+	cfg := Default()
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("JSON Decode error: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate returns an error that describes every invalid field of the config.
+func (c *Config) Validate() error {
+	var problems []string
+	addProblem := func(format string, args ...interface{}) { problems = append(problems, fmt.Sprintf(format, args...)) }
+
+	if c.Goroutines == 0 {
+		addProblem("Goroutines must be at least 1")
+	} else if c.TotalGamesToPlay < c.Goroutines {
+		addProblem("TotalGamesToPlay must be at least Goroutines (%d), but got %d", c.Goroutines, c.TotalGamesToPlay)
+	}
+	if c.Epsilon < 0 || c.Epsilon > 1 {
+		addProblem("Epsilon must be between 0 and 1, but got %v", c.Epsilon)
+	}
+	if c.LearningRate < 0 {
+		addProblem("LearningRate must not be negative, but got %v", c.LearningRate)
+	}
+	if c.EligibilityDecayRate < 0 || c.EligibilityDecayRate > 1 {
+		addProblem("EligibilityDecayRate must be between 0 and 1, but got %v", c.EligibilityDecayRate)
+	}
+	if c.LearningRateReductionInterval == 0 {
+		addProblem("LearningRateReductionInterval must be at least 1")
+	}
+	if c.LearningRateReductionMultiplier <= 0 {
+		addProblem("LearningRateReductionMultiplier must be positive, but got %v", c.LearningRateReductionMultiplier)
+	}
+	if c.Architecture != nil {
+		if err := c.Architecture.Validate(); err != nil {
+			addProblem("Architecture is invalid: %v", err)
+		}
+	}
+	seenClasses := map[state.Class]bool{}
+	for _, name := range c.ClassNetworks {
+		if class, err := state.ParseClass(name); err != nil {
+			addProblem("ClassNetworks is invalid: %v", err)
+		} else if class == state.ClassContact {
+			addProblem("ClassNetworks must not contain %q, which always has its own network", name)
+		} else if seenClasses[class] {
+			addProblem("ClassNetworks contains %q more than once", name)
+		} else {
+			seenClasses[class] = true
+		}
+	}
+	if c.CheckpointEveryMinutes < 0 {
+		addProblem("CheckpointEveryMinutes must not be negative, but got %v", c.CheckpointEveryMinutes)
+	}
+	if c.CheckpointsToKeep < 1 {
+		addProblem("CheckpointsToKeep must be at least 1, but got %d", c.CheckpointsToKeep)
+	}
+	for name, fp := range map[string]string{"WeightsInFile": c.WeightsInFile, "WeightsOutFile": c.WeightsOutFile} {
+		if fp != "" && !strings.HasSuffix(fp, ".json") {
+			addProblem("%s must have a .json suffix, but got %q", name, fp)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid training config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// FilePathNextTo returns where the config of a run that writes its weights to `weightsFilePath` gets copied to.
+func FilePathNextTo(weightsFilePath string) string {
+	return strings.TrimSuffix(weightsFilePath, ".json") + "_train_config.json"
+}
+
+func (c *Config) Save(w io.Writer) error {
+	text, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("JSON Marshal error: %v", err)
+	}
+	_, err = w.Write(append(text, '\n'))
+	return err
+}
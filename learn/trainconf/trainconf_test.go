@@ -0,0 +1,58 @@
+package trainconf
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	cfg, err := Load(strings.NewReader(`{"TotalGamesToPlay": 100, "Goroutines": 4, "Epsilon": 0.1, "LearningRate": 0.001}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Default()
+	want.TotalGamesToPlay, want.Goroutines, want.Epsilon, want.LearningRate = 100, 4, 0.1, 0.001
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("expected unset fields to keep their defaults. wanted %+v but got %+v", want, cfg)
+	}
+
+	var buf bytes.Buffer
+	if err := cfg.Save(&buf); err != nil {
+		t.Fatalf("unexpected error saving: %v", err)
+	}
+	if reloaded, err := Load(&buf); err != nil || !reflect.DeepEqual(reloaded, cfg) {
+		t.Errorf("expected a saved config to load back the same, but got %+v, %v", reloaded, err)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for _, c := range []struct{ json, wantErr string }{
+		{`{"Epsilon": 1.5}`, "Epsilon"},
+		{`{"Goroutines": 0}`, "Goroutines"},
+		{`{"Goroutines": 8, "TotalGamesToPlay": 7}`, "TotalGamesToPlay"},
+		{`{"EligibilityDecayRate": -0.1}`, "EligibilityDecayRate"},
+		{`{"LearningRateReductionInterval": 0}`, "LearningRateReductionInterval"},
+		{`{"WeightsOutFile": "weights.txt"}`, "WeightsOutFile"},
+		{`{"CheckpointsToKeep": 0}`, "CheckpointsToKeep"},
+		{`{"CheckpointEveryMinutes": -1}`, "CheckpointEveryMinutes"},
+		{`{"Architecture": {"HiddenLayers": [{"Size": 0, "Activation": "tanh"}]}}`, "Architecture"},
+		{`{"Architecture": {"HiddenLayers": [{"Size": 8, "Activation": "swish"}]}}`, "Architecture"},
+		{`{"ClassNetworks": ["race", "endgame"]}`, "ClassNetworks"},
+		{`{"ClassNetworks": ["contact"]}`, "ClassNetworks"},
+		{`{"ClassNetworks": ["race", "race"]}`, "ClassNetworks"},
+		{`{"Epsilonn": 0.5}`, "unknown field"},
+		{`{`, "JSON"},
+	} {
+		if _, err := Load(strings.NewReader(c.json)); err == nil || !strings.Contains(err.Error(), c.wantErr) {
+			t.Errorf("expected an error about %s for %s, but got %v", c.wantErr, c.json, err)
+		}
+	}
+}
+
+func TestFilePathNextTo(t *testing.T) {
+	if got, want := FilePathNextTo("/a/bgo_nnet.json"), "/a/bgo_nnet_train_config.json"; got != want {
+		t.Errorf("wanted %q but got %q", want, got)
+	}
+}
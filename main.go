@@ -1,356 +1,173 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"os/user"
-	"runtime"
-	"strconv"
-	"strings"
-	"sync"
-	"sync/atomic"
-	"time"
+	"sort"
+	"syscall"
 
-	"github.com/seriesoftubes/bgo/ctrl"
+	"github.com/seriesoftubes/bgo/learn"
+	"github.com/seriesoftubes/bgo/learn/bearoff"
+	"github.com/seriesoftubes/bgo/learn/met"
 	"github.com/seriesoftubes/bgo/learn/nnet"
-	"github.com/seriesoftubes/bgo/learn/nnet/nnperf"
+	"github.com/seriesoftubes/bgo/render"
 )
 
-const (
-	cmdAverageVariance                           = "avgvar"
-	cmdTotalVariance                             = "ttlvar"
-	cmdCFG                                       = "cfg"
-	cmdprefixMultiplyLearningRate                = "mulr_"
-	cmdprefixChangeLearningRateReducerInterval   = "interval_"
-	cmdprefixChangeLearningRateReducerMultiplier = "multiplier_"
-	cmdHelp                                      = "help"
-)
-
-var (
-	totalGamesToPlayPtr = flag.Uint64("total_games_to_play", 7*60000, "The total number of games to play across all goroutines")
-	numGoroutinesPtr    = flag.Uint64("goroutines", uint64(runtime.NumCPU()/2), "The number of goroutines to run on")
-	epsilonPtr          = flag.Float64("epsilon", 1.0, "The chance (number between 0 and 1.0) that an agent picks a random move instead of an optimal one")
-	inFilePathPtr       = flag.String("config_infile", "", "The file that contains the initial neural net config")
-	outFilePathPtr      = flag.String("config_outfile", "", "The file that will contain the updated neural net config")
-	skipTraining        = flag.Bool("skip_training", false, "Whether to skip training.")
-)
+// subcommands maps the name of each subcommand to a description and the function that runs it with the remaining arguments.
+var subcommands = map[string]struct {
+	desc string
+	run  func(args []string)
+}{
+	cmdTrain:      {"Train the neural network by playing it against itself", runTrainCmd},
+	cmdPlay:       {"Play a single game, e.g. as a human against an agent", runPlayCmd},
+	cmdEval:       {"Evaluate a single position, and rank the turns for a roll", runEvalCmd},
+	cmdAnalyze:    {"Judge every turn and roll of a saved game", runAnalyzeCmd},
+	cmdTournament: {"Play bots against each other and rate them", runTournamentCmd},
+	cmdServe:      {"Serve position evaluations over HTTP as JSON", runServeCmd},
+	cmdBearoff:    {"Generate or verify the bearoff databases", runBearoffCmd},
+}
 
-type (
-	learningRateManager struct {
-		sync.RWMutex
-		interval   uint64  // Every `interval` games, we update the learningRate
-		multiplier float32 // Every `interval` games, we multiply the learningRate by `multiplier`
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(2)
 	}
 
-	// Gotta train'em all! Poke-model!
-	pokemodelTrainer struct {
-		configInFile, configOutFile, varianceLogsFilePath string
-		hasLoadedNN                                       bool
-		startGamesPlayed, gamesPlayed                     uint64
-		lrManager                                         *learningRateManager
+	sc, ok := subcommands[os.Args[1]]
+	if !ok {
+		if name := os.Args[1]; name != "help" && name != "-h" && name != "-help" && name != "--help" {
+			fmt.Printf("unknown command %q\n\n", name)
+		}
+		printUsage()
+		os.Exit(2)
 	}
-)
+	sc.run(os.Args[2:])
+}
 
-func newTrainer(configInFile, configOutFile string) *pokemodelTrainer {
-	if !strings.HasSuffix(configInFile, ".json") || !strings.HasSuffix(configOutFile, ".json") {
-		panic("both the infile and outfile must have a .json suffix.")
+func printUsage() {
+	fmt.Printf("usage: %s <command> [flags]\n\ncommands:\n", os.Args[0])
+	var names []string
+	for name := range subcommands {
+		names = append(names, name)
 	}
-
-	varianceLogsFilePath := strings.Replace(configOutFile, ".json", "_variance_report.txt", 1)
-	return &pokemodelTrainer{
-		configInFile:         configInFile,
-		configOutFile:        configOutFile,
-		varianceLogsFilePath: varianceLogsFilePath,
-		lrManager:            &learningRateManager{interval: 30000, multiplier: 0.25},
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("  %-12s%s\n", name, subcommands[name].desc)
 	}
+	fmt.Printf("\nrun '%s <command> -h' to see the flags of a command.\n", os.Args[0])
 }
 
-func onHelpCmd() {
-	fmt.Println("valid commands are:")
-	fmt.Println("'d' or 'r' to repeat the previous command")
-	fmt.Println(cmdAverageVariance)
-	fmt.Println(cmdTotalVariance)
-	fmt.Println(cmdCFG)
-	fmt.Println(cmdprefixMultiplyLearningRate, "(plus a number, like mulr_1.23)")
-	fmt.Println(cmdprefixChangeLearningRateReducerInterval, "(plus a number like bla_123123)")
-	fmt.Println(cmdprefixChangeLearningRateReducerMultiplier, "(plus a number like bla_0.8")
-	fmt.Println(cmdHelp)
-}
-
-func onMulrCmd(cmd string) {
-	factor, err := float32FromCommand(cmd)
-	if err != nil {
-		fmt.Println(err.Error())
-		return
-	}
-
-	fmt.Println("multiplying learning rate by", factor)
-	nnet.MultiplyLearningRate(factor)
+// signalContext returns a context that's canceled on the first SIGINT or SIGTERM, so that work can be wrapped up and saved.
+// A second signal exits right away.
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigChan := make(chan os.Signal, 2)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-sigChan
+		fmt.Printf("\nreceived %v, shutting down. Send it again to exit right away.\n", sig)
+		cancel()
+		sig = <-sigChan
+		fmt.Printf("\nreceived %v again, exiting without saving.\n", sig)
+		os.Exit(1)
+	}()
+	return ctx, cancel
 }
 
-func float32FromCommand(cmd string) (float32, error) {
-	split := strings.Split(cmd, "_")
-	if len(split) != 2 {
-		return 0, fmt.Errorf("invalid command (should look like 'blabla_5.5') got", cmd)
-	}
-
-	factor, err := strconv.ParseFloat(split[1], 32)
+// defaultDir is where files go, unless flags say otherwise.
+func defaultDir() string {
+	u, err := user.Current()
 	if err != nil {
-		return 0, fmt.Errorf("invalid number %s in command %q: %v", split[1], cmd, err)
+		panic("could not get current OS user: " + err.Error())
 	}
-
-	return float32(factor), nil
+	return fmt.Sprintf("%s/Desktop/bgo", u.HomeDir)
 }
 
 func filePathFromFlag(fp *string) string {
 	if fp == nil || *fp == "" {
-		u, err := user.Current()
-		if err != nil {
-			panic("could not get current OS user: " + err.Error())
-		}
-		return fmt.Sprintf("%s/Desktop/bgo/bgo_nnet.json", u.HomeDir)
+		return defaultDir() + "/bgo_nnet.json"
 	}
 
 	return *fp
 }
 
-func (lrm *learningRateManager) maybeChangeLearningRate(numGamesCompleted uint64) {
-	if my_multiplier, my_interval := lrm.params(); numGamesCompleted%my_interval == 0 {
-		fmt.Println("multiplying the neural net's learning rate by", my_multiplier)
-		nnet.MultiplyLearningRate(my_multiplier)
-	}
+// bearoffDirFlag defines the flag of the directory with the bearoff databases on `fs`.
+func bearoffDirFlag(fs *flag.FlagSet) *string {
+	return fs.String("bearoff_dir", "", "The directory with the bearoff databases, whose positions get evaluated exactly instead of by the neural net. Defaults to ~/Desktop/bgo")
 }
 
-func (lrm *learningRateManager) setInterval(newInterval uint64) {
-	defer lrm.Unlock()
-	lrm.Lock()
-
-	fmt.Println("setting learningRateReducerInterval to", newInterval)
-	lrm.interval = newInterval
-}
-
-func (lrm *learningRateManager) setMultiplier(newMultiplier float32) {
-	defer lrm.Unlock()
-	lrm.Lock()
-
-	fmt.Println("setting learningRateReductionMultiplier to", newMultiplier)
-	lrm.multiplier = newMultiplier
-}
-
-func (lrm *learningRateManager) params() (float32, uint64) {
-	defer lrm.RUnlock()
-	lrm.RLock()
-	return lrm.multiplier, lrm.interval
-}
-
-func (pt *pokemodelTrainer) onCfgCmd() {
-	learningRate, decayRate := nnet.LearningParams()
-	multiplier, interval := pt.lrManager.params()
-
-	fmt.Println("learningRate", learningRate)
-	fmt.Println("decayRate", decayRate)
-	fmt.Println("learningRateReductionMultiplier", multiplier)
-	fmt.Println("learningRateReductionInterval", interval)
-	fmt.Println("gamesPlayed", atomic.LoadUint64(&pt.gamesPlayed))
-}
-
-func (pt *pokemodelTrainer) onGameCompleted() {
-	atomic.AddUint64(&pt.gamesPlayed, 1)
-	ct := atomic.LoadUint64(&pt.gamesPlayed)
-	if ct%500 == 0 {
-		fmt.Println(time.Now(), "trained on", ct, "games")
+// useBearoffDatabases makes `nets` evaluate bearoff positions with the databases in `dir`, if there are any.
+func useBearoffDatabases(nets *nnet.Set, dir string) {
+	if dir == "" {
+		dir = defaultDir()
 	}
-	pt.lrManager.maybeChangeLearningRate(ct)
-}
-
-func (pt *pokemodelTrainer) loadNeuralNetwork() {
-	filePath := pt.configInFile
-	fmt.Println("loading neural network config from", filePath)
-
-	f, err := os.Open(filePath)
+	db, err := bearoff.LoadDir(dir)
 	if err != nil {
-		fmt.Printf("could not open neural network file %q. Skipping. %v\n", filePath, err)
-		pt.hasLoadedNN = true
+		panic(err.Error())
+	}
+	if db == nil {
+		fmt.Printf("no bearoff databases in %q. Skipping.\n", dir)
 		return
 	}
-	defer f.Close()
-
-	if existingGamesPlayed, err := nnet.Load(f); err != nil {
-		panic("could not deserialize neural network: " + err.Error())
-	} else {
-		atomic.StoreUint64(&pt.gamesPlayed, existingGamesPlayed)
-		atomic.StoreUint64(&pt.startGamesPlayed, existingGamesPlayed)
+	fmt.Println("loaded the bearoff databases from", dir)
+	nets.SetExactEvaluator(db)
+	if db.OneSided() != nil {
+		render.SetOneSidedDatabase(db.OneSided())
 	}
-
-	pt.hasLoadedNN = true
-	fmt.Println("neural net loaded!")
 }
 
-func (pt *pokemodelTrainer) saveNeuralNetwork(waitForWrites bool) {
-	filePath := pt.configOutFile
-	fmt.Println("saving neural net config to", filePath)
-
-	f, err := os.Create(filePath) // always overwrites the existing file.
-	if err != nil {
-		panic("could not create file: " + err.Error())
-	}
-	defer f.Close()
-
-	if err := nnet.Save(f, atomic.LoadUint64(&pt.gamesPlayed), waitForWrites); err != nil {
-		panic("couldnt save neural network: " + err.Error())
-	}
-
-	fmt.Println("neural net config saved!")
+// filterFlags adds the flags of the filter that picks which turns a search looks further ahead at.
+func filterFlags(fs *flag.FlagSet) (*int, *float64) {
+	numCandidatesPtr := fs.Int("candidates", learn.DefaultFilter.MaxCandidates, "How many of the best turns the search looks further ahead at, at every ply. 0 looks at all of them")
+	maxEquityLossPtr := fs.Float64("max_equity_loss", float64(learn.DefaultFilter.MaxEquityLoss), "If set, the search only looks further ahead at turns that are at most this much worse than the best one, at every ply")
+	return numCandidatesPtr, maxEquityLossPtr
 }
 
-func (pt *pokemodelTrainer) writeVarianceLogs(waitForWrites bool) {
-	filePath := pt.varianceLogsFilePath
-	fmt.Println("saving variance data to", filePath)
-
-	f, err := os.Create(filePath) // always overwrites the existing file.
-	if err != nil {
-		panic("could not create file: " + err.Error())
-	}
-	defer f.Close()
-
-	w := bufio.NewWriter(f)
-	writeLine := func(ln string) {
-		if _, err = w.WriteString(ln); err != nil {
-			panic(fmt.Sprintf("w.WriteString(%q) error: %v", ln, err))
-		}
-	}
-
-	writeLine("GamesPlayed\tAvgVariance\n")
-	startGamesPlayed := atomic.LoadUint64(&pt.startGamesPlayed)
-	for i, v := range nnperf.GameAverageVariances(-1, waitForWrites) {
-		writeLine(fmt.Sprintf("%v\t%v\n", uint64(i)+1+startGamesPlayed, v))
-		if i%1000 == 0 {
-			w.Flush()
-		}
-	}
-	w.Flush()
-
-	fmt.Println("done saving variance data!")
+func metFlag(fs *flag.FlagSet) *string {
+	return fs.String("met", "", "If set, the match equity table to judge match scores with, in gnubg's XML format (like Kazaross-XG2.xml) or as plain text. Defaults to the built-in table")
 }
 
-func (pt *pokemodelTrainer) onChangeLearningRateReducerIntervalCmd(cmd string) {
-	newInterval, err := float32FromCommand(cmd)
-	if err != nil {
-		fmt.Println("could not parse number from cmd", cmd, err.Error())
-		return
+// mustLoadMatchEquityTable loads the match equity table in `filePath`, or returns the built-in one if it's empty.
+func mustLoadMatchEquityTable(filePath string) *met.Table {
+	if filePath == "" {
+		return met.Default()
 	}
-	pt.lrManager.setInterval(uint64(newInterval))
-}
-
-func (pt *pokemodelTrainer) onChangeLearningRateReducerMultiplierCmd(cmd string) {
-	newMultiplier, err := float32FromCommand(cmd)
+	t, err := met.LoadFile(filePath)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		panic(err.Error())
 	}
-	pt.lrManager.setMultiplier(newMultiplier)
+	fmt.Println("loaded the match equity table", t.Name)
+	return t
 }
 
-// TODO: split this into its own struct.
-func (pt *pokemodelTrainer) readCommands(doneChan chan bool) {
-	var previousCmd string
-
-	for {
-		select {
-		case <-doneChan:
-			fmt.Println("done reading model training commands")
-			return
-		default:
-			fmt.Println("\n\nenter a command\n")
-		}
-
-		var rawCmd string
-		fmt.Scanln(&rawCmd)
-		cmd := strings.ToLower(strings.TrimSpace(rawCmd))
-		if cmd == "" {
-			continue
-		}
-
-		cmdWasToRepeatPreviousCommand := cmd == "d" || cmd == "r"
-		if cmdWasToRepeatPreviousCommand {
-			fmt.Printf("repeating command: %q\n", previousCmd)
-			cmd = previousCmd
-		}
-
-		if cmd == cmdAverageVariance {
-			for _, v := range nnperf.GameAverageVariances(30, false) {
-				fmt.Println(v)
-			}
-		} else if cmd == cmdTotalVariance {
-			for _, v := range nnperf.GameTotalVariances(30, false) {
-				fmt.Println(v)
-			}
-		} else if cmd == cmdCFG {
-			pt.onCfgCmd()
-		} else if strings.HasPrefix(cmd, cmdprefixMultiplyLearningRate) {
-			onMulrCmd(cmd)
-		} else if strings.HasPrefix(cmd, cmdprefixChangeLearningRateReducerInterval) {
-			pt.onChangeLearningRateReducerIntervalCmd(cmd)
-		} else if strings.HasPrefix(cmd, cmdprefixChangeLearningRateReducerMultiplier) {
-			pt.onChangeLearningRateReducerMultiplierCmd(cmd)
-		} else if cmd == cmdHelp {
-			onHelpCmd()
-		} else {
-			fmt.Println("unrecognized command", cmd)
-			continue
-		}
+// mustLoadNeuralNetwork loads the neural nets from a file, and from the files next to it of the position classes with their own net.
+func mustLoadNeuralNetwork(filePath string) *nnet.Set {
+	fmt.Println("loading neural network config from", filePath)
 
-		if !cmdWasToRepeatPreviousCommand {
-			previousCmd = cmd
-		}
+	nets, _, err := nnet.LoadSetFiles(filePath)
+	if err != nil {
+		panic(err.Error())
 	}
+	return nets
 }
 
-func (pt *pokemodelTrainer) train(numGoroutines, gamesToPlayPerGoroutine uint64) {
-	if !pt.hasLoadedNN {
-		pt.loadNeuralNetwork()
-	}
-
-	fmt.Printf("training on %d games (%d goroutines X %d games per goroutine)...\n", numGoroutines*gamesToPlayPerGoroutine, numGoroutines, gamesToPlayPerGoroutine)
-	start := time.Now()
-
-	doneChan := make(chan bool, 1)
-	go pt.readCommands(doneChan)
-
-	var wg sync.WaitGroup
-	wg.Add(int(numGoroutines))
-	for i := uint64(0); i < numGoroutines; i++ {
-		go func() {
-			mgr := ctrl.New(false)
-			for j := uint64(0); j < gamesToPlayPerGoroutine; j++ {
-				mgr.PlayOneGame(0, false) // Play 1 game with 0 humans and don't stop learning!
-				mgr.TransmitStatsFromMostRecentGame()
-				pt.onGameCompleted()
-			}
-			mgr.WaitForStats()
-			wg.Done()
-		}()
+// loadNeuralNetworkIfExists is like mustLoadNeuralNetwork, but it returns an untrained neural net if the file doesn't exist.
+func loadNeuralNetworkIfExists(filePath string) *nnet.Set {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		fmt.Printf("neural network file %q doesn't exist. Skipping.\n", filePath)
+		return nnet.NewUntrainedSet()
 	}
-	wg.Wait()
-
-	doneChan <- true
-	close(doneChan)
-	fmt.Printf("trained %d times in %v\n", atomic.LoadUint64(&pt.gamesPlayed)-atomic.LoadUint64(&pt.startGamesPlayed), time.Since(start))
+	return mustLoadNeuralNetwork(filePath)
 }
 
-func main() {
-	flag.Parse()
-
-	trainer := newTrainer(filePathFromFlag(inFilePathPtr), filePathFromFlag(outFilePathPtr))
-	trainer.loadNeuralNetwork()
-	if skipTraining == nil || !(*skipTraining) {
-		trainer.train(*numGoroutinesPtr, *totalGamesToPlayPtr / *numGoroutinesPtr)
-		trainer.saveNeuralNetwork(true /* waitForWrites=true*/)
-		trainer.writeVarianceLogs(true /* waitForWrites=true*/)
+// loadNeuralNetworkIfSet is like mustLoadNeuralNetwork, but it returns an untrained neural net if `filePath` is empty.
+func loadNeuralNetworkIfSet(filePath string) *nnet.Set {
+	if filePath == "" {
+		return nnet.NewUntrainedSet()
 	}
-
-	mgr := ctrl.New(true /* debug=true*/)
-	mgr.PlayOneGame(1, true /* stopLearning=true */)
+	return mustLoadNeuralNetwork(filePath)
 }
@@ -0,0 +1,168 @@
+// Package race contains the counts that players use to judge races: the Keith count, the Thorp count and the effective pip count,
+// and the cube recommendations that come from them.
+package race
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/learn/bearoff"
+	"github.com/seriesoftubes/bgo/state"
+)
+
+// PipsPerRoll is how many pips an average roll moves. Doubles move four dice, so they count four times.
+const PipsPerRoll = 49.0 / 6
+
+// thorpMinCountToIncrease is the Thorp count above which the roller's count goes up by a tenth.
+const thorpMinCountToIncrease = 30
+
+// IsRace returns whether the players can't hit each other anymore.
+func IsRace(b *game.Board) bool { return state.IsRace(b) }
+
+// Pips returns `p`'s pip count on board `b`.
+func Pips(p plyr.Player, b *game.Board) int {
+	pipC, pipCC := b.PipCounts()
+	if p == plyr.PCC {
+		return int(pipCC)
+	}
+	return int(pipC)
+}
+
+// homeCheckers returns how many checkers `p` has on each point of their home board, from the ace point to the 6 point.
+func homeCheckers(p plyr.Player, b *game.Board) [6]int {
+	var out [6]int
+	homeStart, homeEnd := p.HomePointIndices()
+	for i := homeStart; i <= homeEnd; i++ {
+		if pt := b.Points[i]; pt.Owner == p {
+			dist := i - homeStart // The clockwise player's ace point is points[0].
+			if p == plyr.PCC {
+				dist = homeEnd - i
+			}
+			out[dist] = int(pt.NumCheckers)
+		}
+	}
+	return out
+}
+
+func numCheckersLeft(p plyr.Player, b *game.Board) int {
+	total := int(b.BarC)
+	if p == plyr.PCC {
+		total = int(b.BarCC)
+	}
+	for _, pt := range b.Points {
+		if pt.Owner == p {
+			total += int(pt.NumCheckers)
+		}
+	}
+	return total
+}
+
+// KeithCount is `p`'s pip count on board `b`, plus 2 for each checker beyond 1 on the ace point, 1 for each checker beyond 1 on
+// the 2 point, 1 for each checker beyond 3 on the 3 point, and 1 for each of the 4, 5 and 6 points that's empty.
+func KeithCount(p plyr.Player, b *game.Board) int {
+	home := homeCheckers(p, b)
+	count := Pips(p, b)
+	count += 2 * atLeast0(home[0]-1)
+	count += atLeast0(home[1] - 1)
+	count += atLeast0(home[2] - 3)
+	for _, n := range home[3:] {
+		if n == 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// ThorpCount is `p`'s pip count on board `b`, plus 2 for each checker left, plus 1 for each checker on the ace point, minus 1
+// for each point that p holds in their home board.
+func ThorpCount(p plyr.Player, b *game.Board) int {
+	home := homeCheckers(p, b)
+	count := Pips(p, b) + 2*numCheckersLeft(p, b) + home[0]
+	for _, n := range home {
+		if n > 0 {
+			count--
+		}
+	}
+	return count
+}
+
+// EffectivePipCount is how many pips `p`'s position on board `b` is really worth, counting the pips that bearing off wastes.
+// The wastage of the checkers in p's home board comes from the average number of rolls that `oneSided` says it takes to bear
+// them off, and the checkers outside of it are assumed to waste nothing more. It returns false if `oneSided` can't tell.
+func EffectivePipCount(p plyr.Player, b *game.Board, oneSided *bearoff.OneSided) (float32, bool) {
+	if oneSided == nil {
+		return 0, false
+	}
+	meanRolls, ok := oneSided.MeanRolls(p, b)
+	if !ok {
+		return 0, false
+	}
+	var homePips int
+	for i, n := range homeCheckers(p, b) {
+		homePips += (i + 1) * n
+	}
+	return float32(Pips(p, b)) + meanRolls*PipsPerRoll - float32(homePips), true
+}
+
+// Advice is what a count recommends for the cube, when the player who's about to roll is in a race.
+type Advice struct {
+	Double   bool // Whether to double when the cube is in the middle.
+	Redouble bool // Whether to redouble when the player owns the cube.
+	Take     bool // Whether the opponent should take.
+}
+
+func (a Advice) String() string {
+	var parts []string
+	switch {
+	case a.Double && a.Redouble:
+		parts = append(parts, "double")
+	case a.Double:
+		parts = append(parts, "double but don't redouble")
+	default:
+		parts = append(parts, "no double")
+	}
+	if a.Take {
+		parts = append(parts, "take")
+	} else {
+		parts = append(parts, "pass")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// KeithAdvice is the Keith count's advice for `p`, who's about to roll on board `b`. The roller's count goes up by a seventh,
+// then they should double if it's at most 4 more than the opponent's, and redouble if it's at most 3 more. The opponent should
+// take if it's at least 2 more.
+func KeithAdvice(p plyr.Player, b *game.Board) Advice {
+	diff := float32(KeithCount(p, b))*8/7 - float32(KeithCount(p.Enemy(), b))
+	return Advice{Double: diff <= 4, Redouble: diff <= 3, Take: diff >= 2}
+}
+
+// ThorpAdvice is the Thorp count's advice for `p`, who's about to roll on board `b`. If the roller's count is above 30 it goes up
+// by a tenth, then they should double if it's at most 2 more than the opponent's, and redouble if it's at most 1 more. The opponent
+// should take unless it's more than 2 less.
+func ThorpAdvice(p plyr.Player, b *game.Board) Advice {
+	count := float32(ThorpCount(p, b))
+	if count > thorpMinCountToIncrease {
+		count *= 1.1
+	}
+	diff := count - float32(ThorpCount(p.Enemy(), b))
+	return Advice{Double: diff <= 2, Redouble: diff <= 1, Take: diff >= -2}
+}
+
+// Summary describes `p`'s counts on board `b`, like "pips 45, Keith 52, Thorp 58, effective pips 52.3".
+func Summary(p plyr.Player, b *game.Board, oneSided *bearoff.OneSided) string {
+	s := fmt.Sprintf("pips %d, Keith %d, Thorp %d", Pips(p, b), KeithCount(p, b), ThorpCount(p, b))
+	if epc, ok := EffectivePipCount(p, b, oneSided); ok {
+		s += fmt.Sprintf(", effective pips %.1f", epc)
+	}
+	return s
+}
+
+func atLeast0(n int) int {
+	if n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,93 @@
+package race
+
+import (
+	"math"
+	"testing"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/learn/bearoff"
+)
+
+func mustParse(t *testing.T, pos string) *game.Board {
+	b, err := game.ParsePosition(pos)
+	if err != nil {
+		t.Fatalf("unexpected error parsing %q: %v", pos, err)
+	}
+	return b
+}
+
+func TestIsRace(t *testing.T) {
+	for pos, want := range map[string]bool{
+		"s:X5 t:X5 u:X5 f:O5 e:O5 d:O5": true,
+		"a:O2 b:O3 x:X2 s:X1":           true,
+		"a:X2 f:X13 s:O13 x:O2":         false, // Both players are still back, so they have to pass each other.
+		"y:X1 s:X7 t:X7 c:O5":           false, // X is on the bar.
+	} {
+		if got := IsRace(mustParse(t, pos)); got != want {
+			t.Errorf("expected IsRace(%q) to be %v but got %v", pos, want, got)
+		}
+	}
+}
+
+func TestCounts(t *testing.T) {
+	for _, c := range []struct {
+		pos               string
+		p                 plyr.Player
+		wantKeith, wantTh int
+	}{
+		{"s:X1 a:O1", plyr.PCC, 8, 7},       // 6 pips, and the 4 and 5 points are empty.
+		{"s:X1 a:O1", plyr.PC, 4, 3},        // 1 pip, and the 4, 5 and 6 points are empty.
+		{"x:X3 a:O1", plyr.PCC, 10, 11},     // 3 pips, 2 extra checkers on the ace point, and the 4, 5 and 6 points are empty.
+		{"m:X2 c:O5 e:O2", plyr.PC, 29, 37}, // 25 pips, 2 extra checkers on the 3 point, and the 4 and 6 points are empty.
+	} {
+		b := mustParse(t, c.pos)
+		if got := KeithCount(c.p, b); got != c.wantKeith {
+			t.Errorf("expected %v's Keith count in %q to be %d but got %d", c.p, c.pos, c.wantKeith, got)
+		}
+		if got := ThorpCount(c.p, b); got != c.wantTh {
+			t.Errorf("expected %v's Thorp count in %q to be %d but got %d", c.p, c.pos, c.wantTh, got)
+		}
+	}
+}
+
+func TestAdvice(t *testing.T) {
+	for _, c := range []struct {
+		pos                  string
+		wantKeith, wantThorp Advice
+	}{
+		{"s:X5 t:X5 u:X5 f:O5 e:O5 d:O5", Advice{false, false, true}, Advice{false, false, true}},   // An even race.
+		{"s:X5 t:X5 u:X3 w:X1 f:O5 e:O5 d:O5", Advice{true, false, true}, Advice{true, true, true}}, // X is 6 pips ahead.
+		{"s:X5 t:X5 u:X3 x:X1 f:O5 e:O5 d:O5", Advice{true, true, true}, Advice{true, true, true}},  // X is 7 pips ahead.
+		{"x:X2 f:O5 e:O5", Advice{true, true, false}, Advice{true, true, false}},                    // X is far ahead.
+		{"s:X5 r:X5 a:O2", Advice{false, false, true}, Advice{false, false, true}},                  // X is far behind.
+	} {
+		b := mustParse(t, c.pos)
+		if got := KeithAdvice(plyr.PCC, b); got != c.wantKeith {
+			t.Errorf("expected the Keith count to advise %q for X in %q but got %q", c.wantKeith, c.pos, got)
+		}
+		if got := ThorpAdvice(plyr.PCC, b); got != c.wantThorp {
+			t.Errorf("expected the Thorp count to advise %q for X in %q but got %q", c.wantThorp, c.pos, got)
+		}
+	}
+}
+
+func TestEffectivePipCount(t *testing.T) {
+	oneSided := bearoff.GenerateOneSided(3)
+	for _, c := range []struct {
+		pos  string
+		want float32
+	}{
+		{"x:X1 a:O1", PipsPerRoll},          // 1 pip that always takes a whole roll.
+		{"x:X3 a:O1", PipsPerRoll * 11 / 6}, // Doubles take 1 roll, anything else takes 2.
+		{"r:X1 x:X1 a:O1", 7 + PipsPerRoll}, // The checker outside of the home board wastes nothing more.
+	} {
+		got, ok := EffectivePipCount(plyr.PCC, mustParse(t, c.pos), oneSided)
+		if !ok || math.Abs(float64(got-c.want)) > 0.01 {
+			t.Errorf("expected X's effective pip count in %q to be %v but got %v, %v", c.pos, c.want, got, ok)
+		}
+	}
+	if _, ok := EffectivePipCount(plyr.PCC, mustParse(t, "x:X4 a:O1"), oneSided); ok {
+		t.Errorf("expected no effective pip count with more checkers than the database has")
+	}
+}
@@ -7,27 +7,45 @@ import (
 	"time"
 )
 
-var (
+var defaultSource = NewSource(time.Now().UnixNano())
+
+// A Source generates random numbers in a threadsafe way. Sources created with the same seed generate the same numbers.
+type Source struct {
 	mu  sync.Mutex
-	gen = rand.New(rand.NewSource(time.Now().UnixNano()))
-)
+	gen *rand.Rand
+}
+
+func NewSource(seed int64) *Source { return &Source{gen: rand.New(rand.NewSource(seed))} }
 
-func Float32Between(min, max float32) float32 {
-	defer mu.Unlock()
-	mu.Lock()
-	return gen.Float32()*(max-min) + min
+func (s *Source) Float32Between(min, max float32) float32 {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	return s.gen.Float32()*(max-min) + min
 }
 
-func Float64() float64 {
-	defer mu.Unlock()
-	mu.Lock()
-	return gen.Float64()
+func (s *Source) Float64() float64 {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	return s.gen.Float64()
 }
 
-func IntBetween(min, max int) int {
-	defer mu.Unlock()
-	mu.Lock()
-	return gen.Intn(max-min+1) + min
+func (s *Source) Int63() int64 {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	return s.gen.Int63()
 }
-func IntUpTo(exclusiveMax int) int    { return IntBetween(0, exclusiveMax-1) }
-func Uint8Between(min, max int) uint8 { return uint8(IntBetween(min, max)) }
+
+func (s *Source) IntBetween(min, max int) int {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	return s.gen.Intn(max-min+1) + min
+}
+func (s *Source) IntUpTo(exclusiveMax int) int    { return s.IntBetween(0, exclusiveMax-1) }
+func (s *Source) Uint8Between(min, max int) uint8 { return uint8(s.IntBetween(min, max)) }
+
+func Float32Between(min, max float32) float32 { return defaultSource.Float32Between(min, max) }
+func Float64() float64                        { return defaultSource.Float64() }
+func Int63() int64                            { return defaultSource.Int63() }
+func IntBetween(min, max int) int             { return defaultSource.IntBetween(min, max) }
+func IntUpTo(exclusiveMax int) int            { return defaultSource.IntUpTo(exclusiveMax) }
+func Uint8Between(min, max int) uint8         { return defaultSource.Uint8Between(min, max) }
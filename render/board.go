@@ -9,6 +9,8 @@ import (
 	"github.com/seriesoftubes/bgo/constants"
 	"github.com/seriesoftubes/bgo/game"
 	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/learn/bearoff"
+	"github.com/seriesoftubes/bgo/race"
 )
 
 const (
@@ -26,6 +28,12 @@ const (
 	blankSpace                    = "   "
 )
 
+// oneSided gives the effective pip counts that PrintBoard shows during races.
+var oneSided *bearoff.OneSided
+
+// SetOneSidedDatabase makes PrintBoard show effective pip counts from `db` during races.
+func SetOneSidedDatabase(db *bearoff.OneSided) { oneSided = db }
+
 func PrintBoard(b *game.Board) {
 	if winner := b.Winner(); winner != 0 {
 		fmt.Println(fmt.Sprintf("\n\n\t\tWINNER: %q (won %d points)", string(winner), b.WinKind()))
@@ -181,6 +189,17 @@ func PrintBoard(b *game.Board) {
 	fmt.Println(prefix + "Pipcounts")
 	pipC, pipCC := b.PipCounts()
 	fmt.Println(prefix + fmt.Sprintf("\t%s's: %d\t%s's: %d", plyr.PCC.Symbol(), pipCC, plyr.PC.Symbol(), pipC))
+	if b.Winner() == 0 && race.IsRace(b) {
+		fmt.Println(prefix + "Race")
+		for _, p := range []plyr.Player{plyr.PCC, plyr.PC} {
+			fmt.Println(prefix + fmt.Sprintf("\t%s's: %s", p.Symbol(), race.Summary(p, b, oneSided)))
+		}
+		for _, p := range []plyr.Player{plyr.PCC, plyr.PC} {
+			fmt.Println(prefix + fmt.Sprintf("\tIf %s is on roll, Keith says %s. Thorp says %s", p.Symbol(), race.KeithAdvice(p, b), race.ThorpAdvice(p, b)))
+		}
+	}
+	fmt.Println(prefix + "Position ID")
+	fmt.Println(prefix + "\t" + b.PositionID())
 	fmt.Println(prefix + "\n")
 }
 
@@ -2,11 +2,26 @@ package render
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
 )
 
 func PrintGame(g *game.Game) {
 	fmt.Println(fmt.Sprintf("\n\tPlayer: %s  Rolled: %v", g.CurrentPlayer.Symbol(), g.CurrentRoll))
+	if c := g.Clock; c != nil {
+		fmt.Println(fmt.Sprintf("\tTime left: %s's: %v\t%s's: %v", plyr.PCC.Symbol(), renderDuration(c.Remaining(plyr.PCC)), plyr.PC.Symbol(), renderDuration(c.Remaining(plyr.PC))))
+	}
+	if g.Cube != nil {
+		fmt.Println(fmt.Sprintf("\tCube: %v", g.Cube))
+	}
 	PrintBoard(g.Board)
 }
+
+func renderDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	return d.Truncate(100 * time.Millisecond).String()
+}
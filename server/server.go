@@ -0,0 +1,161 @@
+// Package server serves position evaluations over HTTP as JSON.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+	"github.com/seriesoftubes/bgo/game/turngen"
+	"github.com/seriesoftubes/bgo/learn"
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+const (
+	PathEval   = "/eval"
+	PathHealth = "/healthz"
+
+	defaultNumTurns = 10
+	maxRequestBytes = 1 << 16
+)
+
+type (
+	// An EvalRequest asks for the equity of a position, and optionally the best turns for a roll.
+	EvalRequest struct {
+		Position string // A position ID or text. Defaults to the starting position.
+		Player   string // The player who's about to roll: "X" or "O".
+		Roll     string // If set, the roll (like "31") whose turns get ranked.
+		Ply      int
+		NumTurns int // The max number of ranked turns to return. Defaults to 10.
+	}
+
+	EvalResponse struct {
+		PositionID    string
+		PositionText  string
+		Player        string
+		Ply           int
+		Equity        float32 // The cubeless equity of the player who's about to roll.
+		Probabilities Probabilities
+		Turns         []RankedTurn
+	}
+
+	RankedTurn struct {
+		Turn          string // The serialized turn, like "X;q3;s1".
+		Notation      string // The turn in standard notation, like "8/5 6/5".
+		Equity        float32
+		Probabilities Probabilities
+		Ply           int
+	}
+
+	// Probabilities are the chances of each outcome, from the POV of the player. The gammons include the backgammons.
+	Probabilities struct {
+		Win, WinGammon, WinBackgammon float32
+		LoseGammon, LoseBackgammon    float32
+	}
+
+	errorResponse struct {
+		Error string
+	}
+)
+
+// NewHandler returns a handler that evaluates positions with `nets` on POST /eval.
+func NewHandler(nets *nnet.Set) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(PathEval, func(w http.ResponseWriter, r *http.Request) { handleEval(nets, w, r) })
+	mux.HandleFunc(PathHealth, func(w http.ResponseWriter, r *http.Request) { fmt.Fprintln(w, "ok") })
+	return mux
+}
+
+func handleEval(nets *nnet.Set, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{"only POST is allowed"})
+		return
+	}
+
+	var req EvalRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{"invalid JSON request: " + err.Error()})
+		return
+	}
+
+	resp, err := Evaluate(nets, req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Evaluate answers an EvalRequest with `nets`' evaluation.
+func Evaluate(nets *nnet.Set, req EvalRequest) (*EvalResponse, error) {
+	if req.Ply < 0 || req.Ply > learn.MaxPly {
+		return nil, fmt.Errorf("ply must be between 0 and %d, but got %d", learn.MaxPly, req.Ply)
+	}
+	if req.Player != plyr.PCC.Symbol() && req.Player != plyr.PC.Symbol() {
+		return nil, fmt.Errorf("invalid player %q, should be 'X' or 'O'", req.Player)
+	}
+	p := plyr.Player(req.Player[0])
+	if req.NumTurns <= 0 {
+		req.NumTurns = defaultNumTurns
+	}
+
+	b := &game.Board{}
+	b.SetUp()
+	if req.Position != "" {
+		var err error
+		if b, err = game.ParsePosition(req.Position); err != nil {
+			return nil, err
+		}
+	}
+
+	probs := learn.PositionProbabilities(nets, b, p, req.Ply)
+	resp := &EvalResponse{
+		PositionID:    b.PositionID(),
+		PositionText:  b.PositionText(),
+		Player:        req.Player,
+		Ply:           req.Ply,
+		Equity:        probs.Equity(),
+		Probabilities: newProbabilities(probs),
+	}
+	if req.Roll == "" {
+		return resp, nil
+	}
+
+	roll, err := game.ParseRoll(req.Roll)
+	if err != nil {
+		return nil, err
+	}
+	ranked := learn.RankTurns(nets, b, turngen.ValidTurns(b, roll, p), p, req.Ply, req.NumTurns)
+	if len(ranked) > req.NumTurns {
+		ranked = ranked[:req.NumTurns]
+	}
+	resp.Turns = []RankedTurn{}
+	for _, rt := range ranked {
+		resp.Turns = append(resp.Turns, RankedTurn{
+			Turn:          rt.Turn.String(),
+			Notation:      b.Notation(rt.Turn),
+			Equity:        rt.Equity,
+			Probabilities: newProbabilities(rt.Probabilities),
+			Ply:           rt.Ply,
+		})
+	}
+	return resp, nil
+}
+
+func newProbabilities(p nnet.Probabilities) Probabilities {
+	return Probabilities{
+		Win:            p[nnet.OutputWin],
+		WinGammon:      p[nnet.OutputWinGammon],
+		WinBackgammon:  p[nnet.OutputWinBackgammon],
+		LoseGammon:     p[nnet.OutputLoseGammon],
+		LoseBackgammon: p[nnet.OutputLoseBackgammon],
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/seriesoftubes/bgo/learn/nnet"
+)
+
+func TestEval(t *testing.T) {
+	srv := httptest.NewServer(NewHandler(nnet.NewUntrainedSet()))
+	defer srv.Close()
+
+	cases := []struct {
+		body       string
+		wantStatus int
+		wantTurns  int
+	}{
+		{`{"Player": "X"}`, http.StatusOK, 0},
+		{`{"Player": "X", "Roll": "31", "NumTurns": 3}`, http.StatusOK, 3},
+		{`{"Position": "a:X1 x:O1", "Player": "O", "Roll": "21"}`, http.StatusOK, 2},
+		{`{"Player": "Q"}`, http.StatusBadRequest, 0},
+		{`{"Player": "X", "Ply": 9}`, http.StatusBadRequest, 0},
+		{`{"Player": "X", "Position": "nonsense"}`, http.StatusBadRequest, 0},
+		{`not json`, http.StatusBadRequest, 0},
+	}
+	for _, c := range cases {
+		resp, err := http.Post(srv.URL+PathEval, "application/json", strings.NewReader(c.body))
+		if err != nil {
+			t.Fatalf("could not POST %s: %v", c.body, err)
+		}
+
+		var er EvalResponse
+		decodeErr := json.NewDecoder(resp.Body).Decode(&er)
+		resp.Body.Close()
+		if resp.StatusCode != c.wantStatus {
+			t.Errorf("expected status %d for %s but got %d", c.wantStatus, c.body, resp.StatusCode)
+			continue
+		}
+		if c.wantStatus != http.StatusOK {
+			continue
+		}
+		if decodeErr != nil || er.PositionID == "" || len(er.Turns) != c.wantTurns {
+			t.Errorf("expected a position ID and %d turns for %s, but got %+v, %v", c.wantTurns, c.body, er, decodeErr)
+		}
+		if er.Probabilities.Win <= 0 || er.Probabilities.Win >= 1 {
+			t.Errorf("expected a win probability between 0 and 1 for %s, but got %v", c.body, er.Probabilities.Win)
+		}
+	}
+
+	resp, err := http.Get(srv.URL + PathEval)
+	if err != nil {
+		t.Fatalf("could not GET: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected GET to not be allowed, but got status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,118 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+)
+
+// A Class is a kind of position, which can be evaluated by a network of its own.
+type Class uint8
+
+const (
+	ClassContact Class = iota // The players can still hit each other.
+	ClassCrashed              // There's still contact, but a player has so few checkers left in play that their board is crashing.
+	ClassRace                 // The players can't hit each other anymore.
+	ClassBearoff              // A race where both players have all of their checkers in their home boards.
+	NumClasses
+)
+
+// maxCrashedCheckers is how many checkers a player can have left in play, not counting the extra ones that are stuck deep on
+// their ace and deuce points, for the position to count as crashed. It's the same as in gnubg.
+const maxCrashedCheckers = 6
+
+var classNames = [NumClasses]string{"contact", "crashed", "race", "bearoff"}
+
+func (c Class) String() string {
+	if c < NumClasses {
+		return classNames[c]
+	}
+	return fmt.Sprintf("Class(%d)", uint8(c))
+}
+
+// ParseClass reads a class like "race".
+func ParseClass(s string) (Class, error) {
+	for c, name := range classNames {
+		if name == s {
+			return Class(c), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid position class %q, should be one of %v", s, classNames)
+}
+
+// Classify detects the class of a position. It's the same for both players.
+func Classify(b *game.Board) Class {
+	if IsRace(b) {
+		if hasAllCheckersHome(b, plyr.PC) && hasAllCheckersHome(b, plyr.PCC) {
+			return ClassBearoff
+		}
+		return ClassRace
+	}
+	if isCrashed(b, plyr.PC) || isCrashed(b, plyr.PCC) {
+		return ClassCrashed
+	}
+	return ClassContact
+}
+
+func hasAllCheckersHome(b *game.Board, p plyr.Player) bool {
+	homeStart, homeEnd := p.HomePointIndices()
+	for i, pt := range b.Points {
+		if pt.Owner == p && (uint8(i) < homeStart || uint8(i) > homeEnd) {
+			return false
+		}
+	}
+	return true // IsRace already made sure that nobody is on the bar.
+}
+
+// IsRace returns whether the players' checkers are past each other, so that nobody can get hit anymore.
+func IsRace(b *game.Board) bool {
+	if b.BarCC+b.BarC > 0 {
+		return false
+	}
+
+	// The counter-clockwise player moves up from index 0, and the clockwise player moves down from the last index.
+	furthestPCC, furthestPC := len(b.Points), -1
+	for i, pt := range b.Points {
+		if pt.NumCheckers == 0 {
+			continue
+		} else if pt.Owner == plyr.PCC && i < furthestPCC {
+			furthestPCC = i
+		} else if pt.Owner == plyr.PC && i > furthestPC {
+			furthestPC = i
+		}
+	}
+	return furthestPCC > furthestPC
+}
+
+// isCrashed is gnubg's rule for whether `p` has so few checkers left in play that they can't keep a board.
+func isCrashed(b *game.Board, p plyr.Player) bool {
+	homeStart, homeEnd := p.HomePointIndices()
+	acePoint, deucePoint := b.Points[homeStart], b.Points[homeStart+1]
+	total := int(b.BarC)
+	if p == plyr.PCC {
+		acePoint, deucePoint = b.Points[homeEnd], b.Points[homeEnd-1]
+		total = int(b.BarCC)
+	}
+	for _, pt := range b.Points {
+		if pt.Owner == p {
+			total += int(pt.NumCheckers)
+		}
+	}
+	onAce, onDeuce := numCheckersOf(acePoint, p), numCheckersOf(deucePoint, p)
+
+	if total <= maxCrashedCheckers {
+		return true
+	}
+	if onAce > 1 {
+		return total-onAce <= maxCrashedCheckers || (onDeuce > 1 && 1+total-(onAce+onDeuce) <= maxCrashedCheckers)
+	}
+	return onDeuce > 1 && total-(onDeuce-1) <= maxCrashedCheckers
+}
+
+func numCheckersOf(pt *game.BoardPoint, p plyr.Player) int {
+	if pt.Owner != p {
+		return 0
+	}
+	return int(pt.NumCheckers)
+}
@@ -0,0 +1,68 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+)
+
+func TestClassify(t *testing.T) {
+	for pos, want := range map[string]Class{
+		"a:X2 l:O15 s:X5 t:X4 u:X4":     ClassContact,
+		"a:X2 l:O15 x:X3":               ClassCrashed, // X only has 5 checkers left.
+		"a:X2 l:O15 w:X3 x:X10":         ClassCrashed, // X's extra checkers are stuck on the ace and deuce points.
+		"a:X2 l:O15 s:X7 x:X6":          ClassContact,
+		"a:O2 m:X2":                     ClassRace,
+		"a:O2 b:O3 x:X2 s:X1":           ClassBearoff,
+		"c:O5 d:O5 e:O5 y:X1 s:X7 t:X7": ClassContact, // X is on the bar.
+		"a:X2 f:X13 s:O13 x:O2":         ClassContact, // Both players are still back, so they have to pass each other.
+	} {
+		b, err := game.ParsePosition(pos)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %v", pos, err)
+		}
+		if got := Classify(b); got != want {
+			t.Errorf("expected %q to be %v but got %v", pos, want, got)
+		}
+	}
+
+	b := &game.Board{}
+	b.SetUp()
+	if got := Classify(b); got != ClassContact {
+		t.Errorf("expected the starting position to be %v but got %v", ClassContact, got)
+	}
+}
+
+func TestIsRace(t *testing.T) {
+	b := &game.Board{}
+	b.SetUp()
+	if IsRace(b) {
+		t.Errorf("expected the starting position not to be a race")
+	}
+
+	for _, pt := range b.Points {
+		pt.Owner, pt.NumCheckers = 0, 0
+	}
+	b.Points[20] = &game.BoardPoint{Owner: plyr.PCC, NumCheckers: 15}
+	b.Points[3] = &game.BoardPoint{Owner: plyr.PC, NumCheckers: 15}
+	if !IsRace(b) {
+		t.Errorf("expected a race once the players have passed each other")
+	}
+
+	b.BarC, b.Points[3].NumCheckers = 1, 14
+	if IsRace(b) {
+		t.Errorf("expected a checker on the bar to mean there's contact")
+	}
+}
+
+func TestParseClass(t *testing.T) {
+	for c := Class(0); c < NumClasses; c++ {
+		if got, err := ParseClass(c.String()); err != nil || got != c {
+			t.Errorf("expected %v to round-trip, but got %v, %v", c, got, err)
+		}
+	}
+	if _, err := ParseClass("endgame"); err == nil {
+		t.Errorf("expected an error for an unknown class")
+	}
+}
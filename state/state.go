@@ -57,26 +57,10 @@ func DetectState(p plyr.Player, b *game.Board) State {
 }
 
 func isRace(b *game.Board) float32 {
-	if b.BarCC+b.BarC > 0 {
-		return 0.0
+	if IsRace(b) {
+		return 1.0
 	}
-
-	// loop thru points. if you see. PCC -> PC -> PCC, or PC -> PCC -> PC, it's not a race.
-	var hasSwitched bool
-	var currentPlayer plyr.Player
-	for _, pt := range b.Points {
-		if p := pt.Owner; p != 0 {
-			if currentPlayer != 0 && currentPlayer != p {
-				if hasSwitched {
-					return 0.0
-				}
-				hasSwitched = true
-			}
-			currentPlayer = p
-		}
-	}
-
-	return 1.0
+	return 0.0
 }
 
 func descPoint(pt *game.BoardPoint, supposedOwner plyr.Player) []float32 {
@@ -0,0 +1,149 @@
+package tournament
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/seriesoftubes/bgo/ctrl"
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+)
+
+// DuplicateResults summarizes a duplicate dice match between 2 entrants, from A's point of view. Every pair of games is played
+// with the same dice, once with A in each seat, so that most of the dice luck cancels out within the pair.
+type DuplicateResults struct {
+	A, B            string
+	Pairs, Games    int
+	PointsA         int
+	PointsPerGameA  float64
+	NaiveStdErr     float64 // Treats every game as independent.
+	PairedStdErr    float64 // Treats every pair of games as a single sample.
+	NaiveLow95      float64
+	NaiveHigh95     float64
+	PairedLow95     float64
+	PairedHigh95    float64
+	VarianceRatio   float64 // How many times more games the naive method needs to be as precise as the paired one.
+	sumSquaredGames float64
+	sumSquaredPairs float64
+	pairPointsA     int
+}
+
+type duplicateOutcome struct {
+	pointsA [2]int // One per game of the pair.
+}
+
+// RunDuplicate plays `numPairs` pairs of games between `a` and `b` across `numGoroutines` goroutines. The dice of pair #i are
+// seeded by `seed+i`, so the same seed always replays the same dice.
+func RunDuplicate(a, b Entrant, numPairs, numGoroutines int, seed int64) (*DuplicateResults, error) {
+	if numPairs < 1 || numGoroutines < 1 {
+		return nil, fmt.Errorf("need at least 1 pair of games and 1 goroutine, but got %d and %d", numPairs, numGoroutines)
+	}
+
+	jobs := make(chan int64, numGoroutines)
+	outcomes := make(chan duplicateOutcome, numGoroutines)
+	errs := make(chan error, numGoroutines)
+	go func() {
+		for i := 0; i < numPairs; i++ {
+			jobs <- seed + int64(i)
+		}
+		close(jobs)
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+	for i := 0; i < numGoroutines; i++ {
+		go func() {
+			defer wg.Done()
+			if err := playDuplicates([]Entrant{a, b}, jobs, outcomes); err != nil {
+				errs <- err
+				for range jobs { // Drain the remaining jobs so that the producer doesn't block forever.
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(outcomes)
+		close(errs)
+	}()
+
+	res := &DuplicateResults{A: a.Name, B: b.Name}
+	for o := range outcomes {
+		res.record(o)
+	}
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+
+	res.summarize()
+	return res, nil
+}
+
+// playDuplicates plays both games of each pair whose seed is in `jobs`, until there are none left.
+func playDuplicates(entrants []Entrant, jobs <-chan int64, outcomes chan<- duplicateOutcome) error {
+	players := newPlayerCache(entrants)
+	for seed := range jobs {
+		plA, err := players.get(0)
+		if err != nil {
+			return err
+		}
+		plB, err := players.get(1)
+		if err != nil {
+			return err
+		}
+
+		var o duplicateOutcome
+		for i, aIsPCC := range []bool{true, false} {
+			seed := seed
+			if o.pointsA[i], err = playGame(plA, plB, aIsPCC, func(mgr *ctrl.GameController) (plyr.Player, game.WinKind) {
+				return mgr.PlayOneSeededGame(context.Background(), true /* stopLearning=true */, seed)
+			}); err != nil {
+				return fmt.Errorf("pair with seed %d: %v", seed, err)
+			}
+		}
+		outcomes <- o
+	}
+	return nil
+}
+
+func (r *DuplicateResults) record(o duplicateOutcome) {
+	r.Pairs++
+	pairPoints := 0
+	for _, pts := range o.pointsA {
+		r.Games++
+		r.PointsA += pts
+		r.sumSquaredGames += float64(pts * pts)
+		pairPoints += pts
+	}
+	r.pairPointsA += pairPoints
+	r.sumSquaredPairs += float64(pairPoints * pairPoints)
+}
+
+func (r *DuplicateResults) summarize() {
+	_, r.NaiveStdErr = meanWithStdErr(float64(r.PointsA), r.sumSquaredGames, r.Games)
+
+	// The average of a pair's 2 games is one sample, so everything about the pair sums gets halved.
+	meanPairPoints, pairStdErr := meanWithStdErr(float64(r.pairPointsA), r.sumSquaredPairs, r.Pairs)
+	r.PointsPerGameA, r.PairedStdErr = meanPairPoints/2, pairStdErr/2
+
+	r.NaiveLow95, r.NaiveHigh95 = r.PointsPerGameA-z95*r.NaiveStdErr, r.PointsPerGameA+z95*r.NaiveStdErr
+	r.PairedLow95, r.PairedHigh95 = r.PointsPerGameA-z95*r.PairedStdErr, r.PointsPerGameA+z95*r.PairedStdErr
+	if r.PairedStdErr > 0 {
+		r.VarianceRatio = (r.NaiveStdErr * r.NaiveStdErr) / (r.PairedStdErr * r.PairedStdErr)
+	}
+}
+
+// WriteTable writes a human-readable summary of the results.
+func (r *DuplicateResults) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "pairing\tpairs\tgames\tppg (1st)\tnaive SE\tnaive 95% CI\tpaired SE\tpaired 95% CI\tvariance ratio\t")
+	fmt.Fprintf(tw, "%s vs %s\t%d\t%d\t%+.3f\t%.3f\t[%+.3f, %+.3f]\t%.3f\t[%+.3f, %+.3f]\t%.2f\t\n",
+		r.A, r.B, r.Pairs, r.Games, r.PointsPerGameA, r.NaiveStdErr, r.NaiveLow95, r.NaiveHigh95,
+		r.PairedStdErr, r.PairedLow95, r.PairedHigh95, r.VarianceRatio)
+	return tw.Flush()
+}
+
+func (r *DuplicateResults) WriteJSON(w io.Writer) error { return writeJSON(w, r) }
@@ -0,0 +1,368 @@
+// Package tournament plays round-robin tournaments between Players, and rates them.
+package tournament
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/seriesoftubes/bgo/ctrl"
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/plyr"
+)
+
+const (
+	z95          = 1.96 // The z-score of a 95% confidence interval.
+	eloPerLogit  = 400 / math.Ln10
+	maxMMRounds  = 1000
+	mmTolerance  = 1e-9
+	priorPerPair = 0.5 // Each pairing gets this many virtual wins for both sides, so that a perfect score doesn't have an infinite rating.
+)
+
+type (
+	// An Entrant is a named competitor. NewPlayer gets called for each goroutine that plays the entrant's games.
+	Entrant struct {
+		Name      string
+		NewPlayer func() (ctrl.Player, error)
+	}
+
+	// PairingResult summarizes the games between 2 entrants, from A's point of view.
+	PairingResult struct {
+		A, B                 string
+		Games                int
+		WinsA, WinsB         int
+		GammonsA, GammonsB   int // Gammons and backgammons won.
+		PointsA              int
+		PointsPerGameA       float64
+		PointsPerGameLow95A  float64
+		PointsPerGameHigh95A float64
+		sumSquaredPointsA    float64
+	}
+
+	// EntrantResult summarizes all of an entrant's games.
+	EntrantResult struct {
+		Name                                    string
+		Games, Wins, Points                     int
+		PointsPerGame                           float64
+		PointsPerGameLow95, PointsPerGameHigh95 float64
+		GammonRate, GammonLossRate              float64 // The share of games that were won/lost by a gammon or backgammon.
+		Elo, EloLow95, EloHigh95                float64
+		gammonsWon, gammonsLost                 int
+		sumSquaredPoints                        float64
+	}
+
+	Results struct {
+		GamesPerPairing int
+		Entrants        []*EntrantResult
+		Pairings        []*PairingResult
+	}
+
+	gameJob struct {
+		pairingIdx int
+		aIsPCC     bool
+	}
+
+	gameOutcome struct {
+		pairingIdx int
+		pointsA    int // Negative if B won.
+	}
+)
+
+// Run plays `gamesPerPairing` games between every pair of entrants across `numGoroutines` goroutines, and rates the entrants.
+// Each entrant plays half of its games in each seat.
+func Run(entrants []Entrant, gamesPerPairing, numGoroutines int) (*Results, error) {
+	if len(entrants) < 2 {
+		return nil, fmt.Errorf("need at least 2 entrants, but got %d", len(entrants))
+	} else if gamesPerPairing < 1 || numGoroutines < 1 {
+		return nil, fmt.Errorf("need at least 1 game per pairing and 1 goroutine, but got %d and %d", gamesPerPairing, numGoroutines)
+	}
+
+	res := &Results{GamesPerPairing: gamesPerPairing}
+	var pairs [][2]int
+	for i := range entrants {
+		res.Entrants = append(res.Entrants, &EntrantResult{Name: entrants[i].Name})
+		for j := i + 1; j < len(entrants); j++ {
+			pairs = append(pairs, [2]int{i, j})
+			res.Pairings = append(res.Pairings, &PairingResult{A: entrants[i].Name, B: entrants[j].Name})
+		}
+	}
+
+	jobs := make(chan gameJob, numGoroutines)
+	outcomes := make(chan gameOutcome, numGoroutines)
+	errs := make(chan error, numGoroutines)
+	go func() {
+		for g := 0; g < gamesPerPairing; g++ {
+			for pi := range pairs {
+				jobs <- gameJob{pairingIdx: pi, aIsPCC: g%2 == 0}
+			}
+		}
+		close(jobs)
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+	for i := 0; i < numGoroutines; i++ {
+		go func() {
+			defer wg.Done()
+			if err := playGames(entrants, pairs, jobs, outcomes); err != nil {
+				errs <- err
+				for range jobs { // Drain the remaining jobs so that the producer doesn't block forever.
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(outcomes)
+		close(errs)
+	}()
+
+	for o := range outcomes {
+		res.record(pairs[o.pairingIdx], o)
+	}
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+
+	res.summarize(pairs)
+	return res, nil
+}
+
+// playGames plays the games in `jobs` until there are none left. Players are created once per goroutine and reused.
+func playGames(entrants []Entrant, pairs [][2]int, jobs <-chan gameJob, outcomes chan<- gameOutcome) error {
+	players := newPlayerCache(entrants)
+	for job := range jobs {
+		pair := pairs[job.pairingIdx]
+		plA, err := players.get(pair[0])
+		if err != nil {
+			return err
+		}
+		plB, err := players.get(pair[1])
+		if err != nil {
+			return err
+		}
+
+		pointsA, err := playGame(plA, plB, job.aIsPCC, func(mgr *ctrl.GameController) (plyr.Player, game.WinKind) {
+			return mgr.PlayOneGame(context.Background(), true /* stopLearning=true */)
+		})
+		if err != nil {
+			return fmt.Errorf("entrants %q and %q: %v", entrants[pair[0]].Name, entrants[pair[1]].Name, err)
+		}
+		outcomes <- gameOutcome{pairingIdx: job.pairingIdx, pointsA: pointsA}
+	}
+	return nil
+}
+
+// playerCache creates each entrant's Player the first time it's needed.
+type playerCache struct {
+	entrants []Entrant
+	players  []ctrl.Player
+}
+
+func newPlayerCache(entrants []Entrant) *playerCache {
+	return &playerCache{entrants: entrants, players: make([]ctrl.Player, len(entrants))}
+}
+
+func (pc *playerCache) get(idx int) (ctrl.Player, error) {
+	if pc.players[idx] == nil {
+		pl, err := pc.entrants[idx].NewPlayer()
+		if err != nil {
+			return nil, fmt.Errorf("could not create a player for entrant %q: %v", pc.entrants[idx].Name, err)
+		}
+		pc.players[idx] = pl
+	}
+	return pc.players[idx], nil
+}
+
+// playGame seats `plA` and `plB`, lets `play` play the game, and returns how many points A won (negative if B won). It returns an
+// error if the game was abandoned, like when a scripted player's script ran out, since there's no result to count.
+func playGame(plA, plB ctrl.Player, aIsPCC bool, play func(*ctrl.GameController) (plyr.Player, game.WinKind)) (int, error) {
+	seatA, mgr := plyr.PCC, ctrl.NewWithPlayers(false, plA, plB)
+	if !aIsPCC {
+		seatA, mgr = plyr.PC, ctrl.NewWithPlayers(false, plB, plA)
+	}
+	winner, wk := play(mgr)
+
+	switch winner {
+	case 0:
+		return 0, fmt.Errorf("a game was abandoned")
+	case seatA:
+		return int(wk), nil
+	}
+	return -int(wk), nil
+}
+
+func (r *Results) record(pair [2]int, o gameOutcome) {
+	pr, a, b := r.Pairings[o.pairingIdx], r.Entrants[pair[0]], r.Entrants[pair[1]]
+	pr.Games++
+	pr.PointsA += o.pointsA
+	pr.sumSquaredPointsA += float64(o.pointsA * o.pointsA)
+
+	winner, loser, pointsWon := a, b, o.pointsA
+	if o.pointsA > 0 {
+		pr.WinsA++
+		if o.pointsA > int(game.WinKindSingleGame) {
+			pr.GammonsA++
+		}
+	} else {
+		pr.WinsB++
+		if -o.pointsA > int(game.WinKindSingleGame) {
+			pr.GammonsB++
+		}
+		winner, loser, pointsWon = b, a, -o.pointsA
+	}
+
+	for _, er := range []*EntrantResult{winner, loser} {
+		er.Games++
+		er.sumSquaredPoints += float64(pointsWon * pointsWon)
+	}
+	winner.Wins++
+	winner.Points += pointsWon
+	loser.Points -= pointsWon
+	if pointsWon > int(game.WinKindSingleGame) {
+		winner.gammonsWon++
+		loser.gammonsLost++
+	}
+}
+
+func (r *Results) summarize(pairs [][2]int) {
+	for _, pr := range r.Pairings {
+		pr.PointsPerGameA, pr.PointsPerGameLow95A, pr.PointsPerGameHigh95A = meanWithInterval(pr.PointsA, pr.sumSquaredPointsA, pr.Games)
+	}
+	for _, er := range r.Entrants {
+		er.PointsPerGame, er.PointsPerGameLow95, er.PointsPerGameHigh95 = meanWithInterval(er.Points, er.sumSquaredPoints, er.Games)
+		if er.Games > 0 {
+			er.GammonRate = float64(er.gammonsWon) / float64(er.Games)
+			er.GammonLossRate = float64(er.gammonsLost) / float64(er.Games)
+		}
+	}
+
+	wins := make([][]float64, len(r.Entrants))
+	for i := range wins {
+		wins[i] = make([]float64, len(r.Entrants))
+	}
+	for pi, pair := range pairs {
+		wins[pair[0]][pair[1]] = float64(r.Pairings[pi].WinsA)
+		wins[pair[1]][pair[0]] = float64(r.Pairings[pi].WinsB)
+	}
+	elos, stdErrs := bradleyTerryElos(wins)
+	for i, er := range r.Entrants {
+		er.Elo, er.EloLow95, er.EloHigh95 = elos[i], elos[i]-z95*stdErrs[i], elos[i]+z95*stdErrs[i]
+	}
+}
+
+// meanWithInterval returns the mean of some samples along with its 95% confidence interval.
+func meanWithInterval(sum int, sumSquares float64, n int) (float64, float64, float64) {
+	mean, stdErr := meanWithStdErr(float64(sum), sumSquares, n)
+	return mean, mean - z95*stdErr, mean + z95*stdErr
+}
+
+// meanWithStdErr returns the mean of some samples along with the standard error of that mean.
+func meanWithStdErr(sum, sumSquares float64, n int) (float64, float64) {
+	if n == 0 {
+		return 0, 0
+	}
+
+	mean := sum / float64(n)
+	if n == 1 {
+		return mean, 0
+	}
+	variance := (sumSquares - float64(n)*mean*mean) / float64(n-1)
+	return mean, math.Sqrt(math.Max(variance, 0) / float64(n))
+}
+
+// bradleyTerryElos fits a Bradley-Terry model to a matrix where wins[i][j] is the number of times i beat j, and returns each
+// player's Elo rating (averaging 0 across all players) along with its standard error.
+func bradleyTerryElos(wins [][]float64) ([]float64, []float64) {
+	n := len(wins)
+	games := func(i, j int) float64 { return wins[i][j] + wins[j][i] + 2*priorPerPair }
+
+	strengths := make([]float64, n)
+	for i := range strengths {
+		strengths[i] = 1
+	}
+	for round := 0; round < maxMMRounds; round++ {
+		var maxChange float64
+		for i := 0; i < n; i++ {
+			var numWins, denom float64
+			for j := 0; j < n; j++ {
+				if i == j {
+					continue
+				}
+				numWins += wins[i][j] + priorPerPair
+				denom += games(i, j) / (strengths[i] + strengths[j])
+			}
+			updated := numWins / denom
+			maxChange = math.Max(maxChange, math.Abs(updated-strengths[i]))
+			strengths[i] = updated
+		}
+		if maxChange < mmTolerance {
+			break
+		}
+	}
+
+	elos, stdErrs := make([]float64, n), make([]float64, n)
+	var meanLogit float64
+	for i, s := range strengths {
+		elos[i] = math.Log(s)
+		meanLogit += elos[i] / float64(n)
+	}
+	for i := range elos {
+		var information float64
+		for j := 0; j < n; j++ {
+			if i == j {
+				continue
+			}
+			p := strengths[i] / (strengths[i] + strengths[j])
+			information += games(i, j) * p * (1 - p)
+		}
+		elos[i] = (elos[i] - meanLogit) * eloPerLogit
+		stdErrs[i] = eloPerLogit / math.Sqrt(information)
+	}
+	return elos, stdErrs
+}
+
+// WriteTable writes a human-readable summary of the results, with the best-rated entrants first.
+func (r *Results) WriteTable(w io.Writer) error {
+	sorted := append([]*EntrantResult{}, r.Entrants...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Elo > sorted[j].Elo })
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "entrant\tgames\tppg\tppg 95% CI\twin%\tgammon%\tgammon loss%\telo\telo 95% CI\t")
+	for _, er := range sorted {
+		fmt.Fprintf(tw, "%s\t%d\t%+.3f\t[%+.3f, %+.3f]\t%.1f\t%.1f\t%.1f\t%.0f\t[%.0f, %.0f]\t\n",
+			er.Name, er.Games, er.PointsPerGame, er.PointsPerGameLow95, er.PointsPerGameHigh95, percent(er.Wins, er.Games),
+			100*er.GammonRate, 100*er.GammonLossRate, er.Elo, er.EloLow95, er.EloHigh95)
+	}
+	fmt.Fprintln(tw, "\t\t\t\t\t\t\t\t\t")
+	fmt.Fprintln(tw, "pairing\tgames\tppg (1st)\tppg 95% CI\twin% (1st)\tgammons (1st)\tgammons (2nd)\t\t\t")
+	for _, pr := range r.Pairings {
+		fmt.Fprintf(tw, "%s vs %s\t%d\t%+.3f\t[%+.3f, %+.3f]\t%.1f\t%d\t%d\t\t\t\n",
+			pr.A, pr.B, pr.Games, pr.PointsPerGameA, pr.PointsPerGameLow95A, pr.PointsPerGameHigh95A, percent(pr.WinsA, pr.Games),
+			pr.GammonsA, pr.GammonsB)
+	}
+	return tw.Flush()
+}
+
+func (r *Results) WriteJSON(w io.Writer) error { return writeJSON(w, r) }
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("JSON Encode error: %v", err)
+	}
+	return nil
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
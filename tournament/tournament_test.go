@@ -0,0 +1,140 @@
+package tournament
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/seriesoftubes/bgo/ctrl"
+	"github.com/seriesoftubes/bgo/game"
+	"github.com/seriesoftubes/bgo/game/turn"
+)
+
+func TestBradleyTerryElos(t *testing.T) {
+	cases := []struct {
+		wins    [][]float64
+		wantElo []float64
+	}{
+		{
+			[][]float64{{0, 50}, {50, 0}},
+			[]float64{0, 0},
+		},
+		{
+			// With the prior, 0 beats 1 in 75.5 out of 101 games.
+			[][]float64{{0, 75}, {25, 0}},
+			[]float64{94.3, -94.3},
+		},
+		{
+			[][]float64{{0, 75, 0}, {25, 0, 75}, {0, 25, 0}},
+			[]float64{185.0, 0, -185.0},
+		},
+	}
+	for _, c := range cases {
+		gotElo, gotStdErrs := bradleyTerryElos(c.wins)
+		for i := range c.wantElo {
+			if math.Abs(gotElo[i]-c.wantElo[i]) > 0.5 {
+				t.Errorf("expected elo #%d of %v to be %v but got %v", i, c.wins, c.wantElo[i], gotElo[i])
+			}
+			if gotStdErrs[i] <= 0 || math.IsInf(gotStdErrs[i], 0) {
+				t.Errorf("expected a finite, positive standard error for elo #%d of %v but got %v", i, c.wins, gotStdErrs[i])
+			}
+		}
+	}
+}
+
+func TestMeanWithInterval(t *testing.T) {
+	// Samples: 1, -1, 2, -2
+	mean, low, high := meanWithInterval(0, 10, 4)
+	wantHalfWidth := z95 * math.Sqrt(10.0/3/4)
+	if mean != 0 || math.Abs(high-wantHalfWidth) > 1e-9 || math.Abs(low+wantHalfWidth) > 1e-9 {
+		t.Errorf("expected 0 +/- %v but got %v [%v, %v]", wantHalfWidth, mean, low, high)
+	}
+}
+
+func TestRun(t *testing.T) {
+	newRandom := func() (ctrl.Player, error) { return &ctrl.RandomPlayer{}, nil }
+	entrants := []Entrant{{"a", newRandom}, {"b", newRandom}, {"c", newRandom}}
+
+	res, err := Run(entrants, 4, 3)
+	if err != nil {
+		t.Fatalf("could not run tournament: %v", err)
+	}
+
+	if len(res.Pairings) != 3 {
+		t.Errorf("expected 3 pairings but got %d", len(res.Pairings))
+	}
+	var totalPoints int
+	for _, er := range res.Entrants {
+		if er.Games != 8 {
+			t.Errorf("expected entrant %s to play 8 games but got %d", er.Name, er.Games)
+		}
+		totalPoints += er.Points
+	}
+	if totalPoints != 0 {
+		t.Errorf("expected the entrants' points to add up to 0 but got %d", totalPoints)
+	}
+
+	var buf bytes.Buffer
+	if err := res.WriteJSON(&buf); err != nil {
+		t.Fatalf("could not write JSON: %v", err)
+	}
+	var decoded Results
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded.Entrants) != 3 {
+		t.Errorf("could not decode the written JSON: %v", err)
+	}
+}
+
+func TestRunAbandonedGame(t *testing.T) {
+	newRandom := func() (ctrl.Player, error) { return &ctrl.RandomPlayer{}, nil }
+	newEmptyScript := func() (ctrl.Player, error) { return ctrl.NewScriptedPlayer(nil, false), nil }
+	if _, err := Run([]Entrant{{"a", newRandom}, {"b", newEmptyScript}}, 2, 1); err == nil {
+		t.Errorf("expected an error when a game is abandoned, since it has no result to count")
+	}
+}
+
+// firstTurnPlayer always plays the valid turn that serializes first, so it plays the same way whenever the dice are the same.
+type firstTurnPlayer struct{}
+
+func (fp *firstTurnPlayer) ChooseTurn(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, timeout <-chan time.Time) (turn.Turn, game.WinKind, bool) {
+	var first turn.Turn
+	for _, t := range validTurns {
+		if first == nil || t.String() < first.String() {
+			first = t
+		}
+	}
+	return first, game.WinKindNotWon, true
+}
+
+func (fp *firstTurnPlayer) AcceptsResignation(g *game.Game, validTurns map[turn.TurnArray]turn.Turn, offer game.WinKind, timeout <-chan time.Time) (bool, bool) {
+	return false, true
+}
+
+func (fp *firstTurnPlayer) OffersDouble(g *game.Game, timeout <-chan time.Time) (bool, bool) {
+	return false, true
+}
+
+func (fp *firstTurnPlayer) AcceptsDouble(g *game.Game, timeout <-chan time.Time) (bool, bool) {
+	return true, true
+}
+
+func TestRunDuplicate(t *testing.T) {
+	newFirstTurn := func() (ctrl.Player, error) { return &firstTurnPlayer{}, nil }
+
+	res, err := RunDuplicate(Entrant{"a", newFirstTurn}, Entrant{"b", newFirstTurn}, 5, 2, 42)
+	if err != nil {
+		t.Fatalf("could not run duplicate match: %v", err)
+	}
+
+	if res.Pairs != 5 || res.Games != 10 {
+		t.Errorf("expected 5 pairs and 10 games but got %d and %d", res.Pairs, res.Games)
+	}
+	// Identical players with identical dice get identical results from the same seat, so every pair cancels out.
+	if res.PointsPerGameA != 0 || res.PairedStdErr != 0 {
+		t.Errorf("expected identical players to break even with no paired error, but got %v +/- %v", res.PointsPerGameA, res.PairedStdErr)
+	}
+	if res.NaiveStdErr <= 0 {
+		t.Errorf("expected a positive naive standard error but got %v", res.NaiveStdErr)
+	}
+}